@@ -0,0 +1,210 @@
+// Command scrape fetches urls concurrently and prints or writes the results.
+//
+// Urls come from the arguments, from a file given with -f, or from stdin when
+// there are neither or -f is "-":
+//
+//	scrape -c 10 -format ndjson -o results.ndjson https://example.com https://go.dev
+//	cat urls.txt | scrape -format csv
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"test/learning"
+	"test/learning/results"
+)
+
+// headerFlags collects repeated -H "Name: value" flags
+type headerFlags http.Header
+
+func (h headerFlags) String() string {
+	return fmt.Sprint(http.Header(h))
+}
+
+func (h headerFlags) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header %q is not in the form Name: value", value)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(val))
+	return nil
+}
+
+// errFailed makes the command exit with status 2 when some urls failed
+var errFailed = errors.New("some urls failed")
+
+func main() {
+	err := run()
+	switch {
+	case errors.Is(err, errFailed):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "scrape:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		workers   = flag.Int("c", 5, "number of urls scraped at the same time")
+		timeout   = flag.Duration("timeout", 10*time.Second, "timeout per request")
+		total     = flag.Duration("total-timeout", 0, "timeout for the whole run, 0 means none")
+		retries   = flag.Int("retries", 0, "retries for failed requests")
+		format    = flag.String("format", "text", "output format: text, json, ndjson or csv")
+		output    = flag.String("o", "", "write the results to this file instead of stdout")
+		urlFile   = flag.String("f", "", `read urls from this file, one per line, "-" for stdin`)
+		body      = flag.Int("body", 0, "include up to this many bytes of text body in json, ndjson and csv, -1 for all")
+		userAgent = flag.String("user-agent", "", "User-Agent header to send")
+		headers   = headerFlags{}
+	)
+	flag.Var(headers, "H", `extra request header as "Name: value", can be repeated`)
+	flag.Parse()
+
+	urls, err := readURLs(flag.Args(), *urlFile)
+	if err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("no urls given")
+	}
+
+	var opts []results.Option
+	switch {
+	case *body < 0:
+		opts = append(opts, results.WithTextBody(0))
+	case *body > 0:
+		opts = append(opts, results.WithTextBody(*body))
+	default:
+		opts = append(opts, results.WithoutBody())
+	}
+	write, err := writer(*format, opts)
+	if err != nil {
+		return err
+	}
+
+	if *userAgent != "" {
+		http.Header(headers).Set("User-Agent", *userAgent)
+	}
+	scraperOpts := []learning.Option{learning.WithHeaders(http.Header(headers))}
+	if *retries > 0 {
+		scraperOpts = append(scraperOpts, learning.WithRetry(learning.RetryPolicy{
+			MaxAttempts:  *retries + 1,
+			InitialDelay: 500 * time.Millisecond,
+			Factor:       2,
+			Jitter:       0.2,
+		}))
+	}
+	scraper := learning.NewSimpleScraper(*timeout, scraperOpts...)
+	defer scraper.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *total > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *total)
+		defer cancel()
+	}
+
+	concurrent := learning.NewConcurrentScraper(scraper, *workers,
+		learning.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, nil))))
+	res, runErr := concurrent.ScrapeWithError(ctx, urls)
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		out = f
+	}
+	if err := write(out, res); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	if f, ok := out.(*os.File); ok && f != os.Stdout {
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+	}
+
+	stats := learning.Summarize(res)
+	fmt.Fprintf(os.Stderr, "%d urls, %d succeeded, %d failed, %d bytes in %s\n",
+		stats.Total, stats.Succeeded, stats.Failed, stats.Bytes, stats.Elapsed.Round(time.Millisecond))
+	if runErr != nil {
+		return runErr
+	}
+	if stats.Failed > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// readURLs returns the urls from args and file, file "-" or no urls at all reads stdin
+func readURLs(args []string, file string) ([]string, error) {
+	urls := append([]string(nil), args...)
+	var r io.Reader
+	switch {
+	case file == "-" || (file == "" && len(args) == 0):
+		r = os.Stdin
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open url file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	default:
+		return urls, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read urls: %w", err)
+	}
+	return urls, nil
+}
+
+// writer returns the function that writes results in format
+func writer(format string, opts []results.Option) (func(io.Writer, []learning.Result) error, error) {
+	switch format {
+	case "text":
+		return writeText, nil
+	case "json":
+		return func(w io.Writer, res []learning.Result) error { return results.WriteJSON(w, res, opts...) }, nil
+	case "ndjson":
+		return func(w io.Writer, res []learning.Result) error { return results.WriteNDJSON(w, res, opts...) }, nil
+	case "csv":
+		return func(w io.Writer, res []learning.Result) error { return results.WriteCSV(w, res, opts...) }, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// writeText writes a line per result
+func writeText(w io.Writer, res []learning.Result) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range res {
+		if r.Err != nil {
+			fmt.Fprintf(bw, "FAIL %s %s\n", r.URL, r.Err)
+			continue
+		}
+		fmt.Fprintf(bw, "%d %s %d bytes %s\n", r.StatusCode, r.URL, len(r.Data), r.Duration.Round(time.Millisecond))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,172 @@
+package learning
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to a request before it is sent
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// invalidator is implemented by authenticators whose credentials can go stale
+type invalidator interface {
+	Invalidate()
+}
+
+// WithAuthenticator authenticates every request of the scraper with auth.
+// Requests with credentials in the url or their own Authorization header are
+// sent as they are.
+func WithAuthenticator(auth Authenticator) Option {
+	return func(s *SimpleScraper) {
+		s.auth = auth
+	}
+}
+
+// BasicAuth sends a username and password with every request
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate implements Authenticator
+func (b BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
+
+// BearerToken sends a static token in the Authorization header
+type BearerToken string
+
+// Authenticate implements Authenticator
+func (t BearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// tokenLeeway is how long before its expiry a token is replaced, so it doesn't
+// run out while a request is on its way
+const tokenLeeway = 30 * time.Second
+
+// OAuth2ClientCredentials gets access tokens from TokenURL with the OAuth2
+// client credentials grant and fetches a new one shortly before it expires
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Client fetches the tokens, http.DefaultClient when nil
+	Client *http.Client
+	// Clock expires the tokens, RealClock when nil
+	Clock Clock
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewOAuth2ClientCredentials creates an OAuth2ClientCredentials authenticator
+func NewOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) *OAuth2ClientCredentials {
+	return &OAuth2ClientCredentials{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+	}
+}
+
+// Authenticate implements Authenticator, concurrent requests wait for the same
+// token refresh
+func (o *OAuth2ClientCredentials) Authenticate(req *http.Request) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token == "" || !o.now().Before(o.expires) {
+		if err := o.refresh(req); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+o.token)
+	return nil
+}
+
+// tokenResponse is the successful answer of a token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// refresh fetches a new token, o.mu must be held
+func (o *OAuth2ClientCredentials) refresh(req *http.Request) error {
+	form := neturl.Values{"grant_type": {"client_credentials"}}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	tokenReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %w", err)
+	}
+	tokenReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tokenReq.Header.Set("Accept", "application/json")
+	tokenReq.SetBasicAuth(neturl.QueryEscape(o.ClientID), neturl.QueryEscape(o.ClientSecret))
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(tokenReq)
+	if err != nil {
+		return fmt.Errorf("failed to fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch token: %w", ErrBadStatus{Code: resp.StatusCode})
+	}
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	o.token = token.AccessToken
+	if token.ExpiresIn > 0 {
+		o.expires = o.now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenLeeway)
+	} else {
+		// a token without expiry is kept until Invalidate
+		o.expires = time.Unix(1<<62, 0)
+	}
+	return nil
+}
+
+// Invalidate drops the current token, the next request fetches a new one.
+// The scraper calls it when a request is answered with 401.
+func (o *OAuth2ClientCredentials) Invalidate() {
+	o.mu.Lock()
+	o.token = ""
+	o.mu.Unlock()
+}
+
+func (o *OAuth2ClientCredentials) now() time.Time {
+	if o.Clock == nil {
+		return RealClock.Now()
+	}
+	return o.Clock.Now()
+}
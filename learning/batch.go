@@ -0,0 +1,26 @@
+package learning
+
+import "context"
+
+// BatchScraper is implemented by scrapers that have an optimized way to scrape many URLs
+type BatchScraper interface {
+	ScrapeMany(ctx context.Context, urls []string) []Result
+}
+
+// ScrapeManyWith scrapes all urls with scraper, using its batch implementation
+// when it has one and falling back to scraping one url after the other
+func ScrapeManyWith(scraper Scraper, ctx context.Context, urls []string) []Result {
+	if batch, ok := scraper.(BatchScraper); ok {
+		return batch.ScrapeMany(ctx, urls)
+	}
+
+	results := make([]Result, 0, len(urls))
+	for _, url := range urls {
+		if err := ctx.Err(); err != nil {
+			results = append(results, Result{URL: url, Err: err})
+			continue
+		}
+		results = append(results, scrapeOne(ctx, scraper, url))
+	}
+	return results
+}
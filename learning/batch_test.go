@@ -0,0 +1,62 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeBatch answers every url of a batch with one ScrapeMany call
+type fakeBatch struct {
+	StubScraper
+	batches int
+}
+
+func (f *fakeBatch) ScrapeMany(ctx context.Context, urls []string) []Result {
+	f.batches++
+	results := make([]Result, len(urls))
+	for i, url := range urls {
+		results[i] = Result{URL: url, Data: []byte("batched")}
+	}
+	return results
+}
+
+func TestScrapeManyWith(t *testing.T) {
+	pages := map[string][]byte{"https://a.com/": []byte("a"), "https://b.com/": []byte("b")}
+	urls := []string{"https://b.com/", "https://a.com/", "https://missing.com/"}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		scraper     Scraper
+		ctx         context.Context
+		want        []string
+		wantErr     []error
+		wantBatches int
+	}{
+		{"sequential", NewStubScraper(pages), context.Background(),
+			[]string{"b", "a", ""}, []error{nil, nil, ErrNotFound}, 0},
+		{"batch", &fakeBatch{}, context.Background(),
+			[]string{"batched", "batched", "batched"}, []error{nil, nil, nil}, 1},
+		{"cancelled", NewStubScraper(pages), cancelled,
+			[]string{"", "", ""}, []error{context.Canceled, context.Canceled, context.Canceled}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := ScrapeManyWith(tt.scraper, tt.ctx, urls)
+			if len(results) != len(urls) {
+				t.Fatalf("got %d results, want %d", len(results), len(urls))
+			}
+			for i, result := range results {
+				if result.URL != urls[i] || string(result.Data) != tt.want[i] || !errors.Is(result.Err, tt.wantErr[i]) {
+					t.Errorf("result %d = %s %q %v, want %s %q %v",
+						i, result.URL, result.Data, result.Err, urls[i], tt.want[i], tt.wantErr[i])
+				}
+			}
+			if fake, ok := tt.scraper.(*fakeBatch); ok && fake.batches != tt.wantBatches {
+				t.Errorf("ScrapeMany was called %d times, want %d", fake.batches, tt.wantBatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package learning
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// URLSet is the set a Deduper keeps its normalized urls in
+type URLSet interface {
+	// Add adds key and reports whether it was new
+	Add(key string) bool
+	Has(key string) bool
+	Len() int
+}
+
+// mapSet is the exact URLSet, it keeps every key in memory
+type mapSet map[string]bool
+
+func (m mapSet) Add(key string) bool {
+	if m[key] {
+		return false
+	}
+	m[key] = true
+	return true
+}
+
+func (m mapSet) Has(key string) bool { return m[key] }
+func (m mapSet) Len() int            { return len(m) }
+
+// BloomFilter is a URLSet that needs a few bits per url instead of the url
+// itself. It never forgets a url, but with the probability it was sized for it
+// claims to have seen one it hasn't, so that url is skipped. It is not safe for
+// concurrent use on its own, a Deduper around it is.
+type BloomFilter struct {
+	bits  []uint64
+	m     uint64
+	k     int
+	count int
+	seed1 maphash.Seed
+	seed2 maphash.Seed
+}
+
+// NewBloomFilter sizes a BloomFilter for expected urls at falsePositiveRate,
+// such as 0.001. Adding more urls than expected raises the rate.
+func NewBloomFilter(expected int, falsePositiveRate float64) *BloomFilter {
+	expected = max(expected, 1)
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(expected) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := int(math.Round(float64(m) / float64(expected) * math.Ln2))
+	return &BloomFilter{
+		bits:  make([]uint64, (m+63)/64),
+		m:     m,
+		k:     max(k, 1),
+		seed1: maphash.MakeSeed(),
+		seed2: maphash.MakeSeed(),
+	}
+}
+
+// Add implements URLSet, it reports false for urls that were probably added before
+func (b *BloomFilter) Add(key string) bool {
+	h1, h2 := b.hash(key)
+	added := false
+	for i := range b.k {
+		bit := (h1 + uint64(i)*h2) % b.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			b.bits[word] |= mask
+			added = true
+		}
+	}
+	if added {
+		b.count++
+	}
+	return added
+}
+
+// Has implements URLSet, false positives are possible and false negatives are not
+func (b *BloomFilter) Has(key string) bool {
+	h1, h2 := b.hash(key)
+	for i := range b.k {
+		bit := (h1 + uint64(i)*h2) % b.m
+		if b.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Len implements URLSet, it counts the urls Add reported as new
+func (b *BloomFilter) Len() int {
+	return b.count
+}
+
+// hash returns the two hashes the k positions are derived from
+func (b *BloomFilter) hash(key string) (uint64, uint64) {
+	// an odd step visits different positions for every i
+	return maphash.String(b.seed1, key), maphash.String(b.seed2, key) | 1
+}
+
+// NewBloomDeduper creates a Deduper backed by a BloomFilter, for crawls too big
+// to keep every url in memory. See NewBloomFilter for the parameters.
+func NewBloomDeduper(expected int, falsePositiveRate float64) *Deduper {
+	return NewDeduperWith(NewBloomFilter(expected, falsePositiveRate))
+}
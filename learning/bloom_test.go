@@ -0,0 +1,29 @@
+package learning
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilterFalsePositives(t *testing.T) {
+	const n = 10000
+	b := NewBloomFilter(n, 0.01)
+	for i := range n {
+		b.Add(fmt.Sprint("https://example.com/a/", i))
+	}
+	for i := range n {
+		if !b.Has(fmt.Sprint("https://example.com/a/", i)) {
+			t.Fatalf("url %d was added but is missing", i)
+		}
+	}
+	falsePositives := 0
+	for i := range n {
+		if b.Has(fmt.Sprint("https://example.com/b/", i)) {
+			falsePositives++
+		}
+	}
+	// twice the configured rate leaves room for chance
+	if rate := float64(falsePositives) / n; rate > 0.02 {
+		t.Errorf("false positive rate %.4f, want about 0.01", rate)
+	}
+}
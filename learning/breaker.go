@@ -0,0 +1,155 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for urls whose host failed too often recently
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitBreakerScraper wraps a Scraper and stops sending requests to a host
+// after MaxFailures consecutive failures. Only transport errors, timeouts and
+// 429 or 5xx responses count as failures, a 404 or a filtered url shows the
+// host is answering. Once Cooldown has passed a single request is let through,
+// its success closes the circuit again and its failure starts another cool-down.
+type CircuitBreakerScraper struct {
+	Scraper     Scraper
+	MaxFailures int
+	Cooldown    time.Duration
+	// Clock measures the cool-down, RealClock when nil
+	Clock Clock
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewCircuitBreakerScraper creates a CircuitBreakerScraper
+func NewCircuitBreakerScraper(scraper Scraper, maxFailures int, cooldown time.Duration) *CircuitBreakerScraper {
+	return &CircuitBreakerScraper{Scraper: scraper, MaxFailures: maxFailures, Cooldown: cooldown}
+}
+
+// CircuitBreaker short-circuits hosts that keep failing, see CircuitBreakerScraper
+func CircuitBreaker(maxFailures int, cooldown time.Duration) Middleware {
+	return func(next Scraper) Scraper {
+		return NewCircuitBreakerScraper(next, maxFailures, cooldown)
+	}
+}
+
+// Name identifies the CircuitBreakerScraper and the scraper it wraps
+func (c *CircuitBreakerScraper) Name() string {
+	return "breaker(" + ScraperName(c.Scraper) + ")"
+}
+
+// Scrape fetches url unless the circuit of its host is open
+func (c *CircuitBreakerScraper) Scrape(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	host := u.Hostname()
+
+	if !c.allow(host) {
+		return nil, fmt.Errorf("%w for %s", ErrCircuitOpen, host)
+	}
+	data, err := c.Scraper.Scrape(ctx, rawURL)
+	// a cancelled batch says nothing about the host
+	if ctx.Err() == nil {
+		c.report(host, err)
+	} else {
+		c.abort(host)
+	}
+	return data, err
+}
+
+// Reset closes every circuit and forgets the failures counted so far, a probe
+// still in flight reports to the fresh state
+func (c *CircuitBreakerScraper) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hosts = map[string]*circuit{}
+}
+
+// Close closes the wrapped scraper
+func (c *CircuitBreakerScraper) Close() error {
+	return closeScraper(c.Scraper)
+}
+
+func (c *CircuitBreakerScraper) now() time.Time {
+	if c.Clock == nil {
+		return RealClock.Now()
+	}
+	return c.Clock.Now()
+}
+
+// allow reports whether a request to host may go ahead, letting a single probe
+// through once the cool-down is over
+func (c *CircuitBreakerScraper) allow(host string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hosts == nil {
+		c.hosts = map[string]*circuit{}
+	}
+	cb := c.hosts[host]
+	if cb == nil || cb.openUntil.IsZero() {
+		return true
+	}
+	if c.now().Before(cb.openUntil) || cb.probing {
+		return false
+	}
+	cb.probing = true
+	return true
+}
+
+func (c *CircuitBreakerScraper) report(host string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !hostFailure(err) {
+		delete(c.hosts, host)
+		return
+	}
+
+	cb := c.hosts[host]
+	if cb == nil {
+		cb = &circuit{}
+		c.hosts[host] = cb
+	}
+	cb.failures++
+	if cb.probing || (c.MaxFailures > 0 && cb.failures >= c.MaxFailures) {
+		cb.openUntil = c.now().Add(c.Cooldown)
+		cb.probing = false
+	}
+}
+
+// abort gives up a probe without judging the host
+func (c *CircuitBreakerScraper) abort(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cb := c.hosts[host]; cb != nil {
+		cb.probing = false
+	}
+}
+
+// hostFailure reports whether err means the host is in trouble rather than
+// that it turned down a single url
+func hostFailure(err error) bool {
+	var bad ErrBadStatus
+	if errors.As(err, &bad) {
+		return bad.Code == http.StatusTooManyRequests || bad.Code >= 500
+	}
+	return errors.Is(err, ErrTimeout) || isTimeout(err) || retryable(err)
+}
@@ -0,0 +1,60 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerReset(t *testing.T) {
+	backend := &failingScraper{err: errors.New("connection refused")}
+	breaker := NewCircuitBreakerScraper(backend, 2, time.Hour)
+	ctx := context.Background()
+
+	for range 2 {
+		breaker.Scrape(ctx, "https://example.com/page")
+	}
+	if _, err := breaker.Scrape(ctx, "https://example.com/page"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v after 2 failures, want %v", err, ErrCircuitOpen)
+	}
+
+	breaker.Reset()
+	backend.err = nil
+	if _, err := breaker.Scrape(ctx, "https://example.com/page"); err != nil {
+		t.Fatalf("got %v after Reset, want the request to go through", err)
+	}
+	if backend.calls != 3 {
+		t.Errorf("backend called %d times, want 3", backend.calls)
+	}
+}
+
+func TestCircuitBreakerFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantOpen bool
+	}{
+		{"not found", ErrBadStatus{Code: 404}, false},
+		{"forbidden", ErrBadStatus{Code: 403}, false},
+		{"robots", fmt.Errorf("%w: u", ErrRobotsDisallowed), false},
+		{"too large", ErrTooLarge, false},
+		{"too many requests", ErrBadStatus{Code: 429}, true},
+		{"server error", ErrBadStatus{Code: 503}, true},
+		{"timeout", markTimeout(context.Background(), context.DeadlineExceeded), true},
+		{"network", errors.New("connection refused"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreakerScraper(&failingScraper{err: tt.err}, 2, time.Hour)
+			for range 2 {
+				breaker.Scrape(context.Background(), "https://example.com/page")
+			}
+			_, err := breaker.Scrape(context.Background(), "https://example.com/page")
+			if open := errors.Is(err, ErrCircuitOpen); open != tt.wantOpen {
+				t.Errorf("circuit open = %v after 2 %v errors, want %v", open, tt.err, tt.wantOpen)
+			}
+		})
+	}
+}
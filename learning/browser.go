@@ -0,0 +1,83 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserScraper renders pages in headless Chrome before returning their html,
+// so pages that build their content with JavaScript can be scraped. Every
+// Scrape opens its own tab in a browser shared for the life of the scraper.
+type BrowserScraper struct {
+	// WaitSelector is waited for before the html is read, body when empty
+	WaitSelector string
+	// Settle gives scripts extra time after WaitSelector appeared
+	Settle time.Duration
+
+	cancelAlloc   context.CancelFunc
+	browser       context.Context
+	cancelBrowser context.CancelFunc
+}
+
+// NewBrowserScraper starts headless Chrome, opts are added to chromedp's defaults,
+// for example chromedp.ExecPath or chromedp.UserAgent
+func NewBrowserScraper(opts ...chromedp.ExecAllocatorOption) (*BrowserScraper, error) {
+	allocOpts := append(chromedp.DefaultExecAllocatorOptions[:], opts...)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+	browser, cancelBrowser := chromedp.NewContext(allocCtx)
+
+	// start the browser now so a missing Chrome is reported here and not on the first scrape
+	if err := chromedp.Run(browser); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	return &BrowserScraper{cancelAlloc: cancelAlloc, browser: browser, cancelBrowser: cancelBrowser}, nil
+}
+
+// Name identifies the BrowserScraper
+func (b *BrowserScraper) Name() string {
+	return "browser"
+}
+
+// Scrape loads url in a new tab and returns the rendered html
+func (b *BrowserScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	tab, cancel := chromedp.NewContext(b.browser)
+	defer cancel()
+	// the tab lives in the browser's context, close it when the caller gives up
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	selector := b.WaitSelector
+	if selector == "" {
+		selector = "body"
+	}
+	actions := []chromedp.Action{
+		chromedp.Navigate(url),
+		chromedp.WaitReady(selector, chromedp.ByQuery),
+	}
+	if b.Settle > 0 {
+		actions = append(actions, chromedp.Sleep(b.Settle))
+	}
+	var html string
+	actions = append(actions, chromedp.OuterHTML("html", &html, chromedp.ByQuery))
+
+	if err := chromedp.Run(tab, actions...); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to render %s: %w", url, err)
+	}
+	return []byte(html), nil
+}
+
+// Close shuts the browser down
+func (b *BrowserScraper) Close() error {
+	b.cancelBrowser()
+	b.cancelAlloc()
+	return nil
+}
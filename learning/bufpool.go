@@ -0,0 +1,70 @@
+package learning
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// BufferPool hands out the buffers response bodies are read into
+type BufferPool interface {
+	Get() *bytes.Buffer
+	Put(buf *bytes.Buffer)
+}
+
+// WithBufferPool reads bodies through buffers from pool instead of the shared
+// default pool, for example to size it for the pages of one site
+func WithBufferPool(pool BufferPool) Option {
+	return func(s *SimpleScraper) {
+		s.buffers = pool
+	}
+}
+
+// SyncBufferPool is a BufferPool backed by a sync.Pool. Buffers that grew beyond
+// MaxSize are dropped instead of kept, so one huge page doesn't pin its memory.
+type SyncBufferPool struct {
+	MaxSize int
+	pool    sync.Pool
+}
+
+// NewSyncBufferPool creates a SyncBufferPool that keeps buffers up to maxSize bytes
+func NewSyncBufferPool(maxSize int) *SyncBufferPool {
+	return &SyncBufferPool{MaxSize: maxSize}
+}
+
+// Get returns an empty buffer
+func (p *SyncBufferPool) Get() *bytes.Buffer {
+	if buf, ok := p.pool.Get().(*bytes.Buffer); ok {
+		return buf
+	}
+	return new(bytes.Buffer)
+}
+
+// Put returns buf to the pool
+func (p *SyncBufferPool) Put(buf *bytes.Buffer) {
+	if p.MaxSize > 0 && buf.Cap() > p.MaxSize {
+		return
+	}
+	buf.Reset()
+	p.pool.Put(buf)
+}
+
+// defaultBufferPool is shared by every scraper without its own pool
+var defaultBufferPool = NewSyncBufferPool(4 << 20)
+
+// readBody reads r through a pooled buffer and returns a copy of exactly the
+// body's size, which is the only allocation once the pool is warm. sizeHint is
+// the Content-Length, or -1 when unknown.
+func readBody(pool BufferPool, r io.Reader, sizeHint int64) ([]byte, error) {
+	buf := pool.Get()
+	defer pool.Put(buf)
+
+	// a wrong Content-Length only costs a bigger buffer, capped to stay sane
+	if sizeHint > 0 && sizeHint < 64<<20 {
+		buf.Grow(int(sizeHint) + bytes.MinRead)
+	}
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
@@ -0,0 +1,33 @@
+package learning
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+const benchBodySize = 256 << 10
+
+func BenchmarkReadBody(b *testing.B) {
+	body := bytes.Repeat([]byte("x"), benchBodySize)
+	b.ReportAllocs()
+	b.SetBytes(benchBodySize)
+	for range b.N {
+		if _, err := io.ReadAll(bytes.NewReader(body)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadBodyPooled(b *testing.B) {
+	body := bytes.Repeat([]byte("x"), benchBodySize)
+	pool := NewSyncBufferPool(0)
+	b.ReportAllocs()
+	b.SetBytes(benchBodySize)
+	for range b.N {
+		// without a Content-Length, like a chunked response
+		if _, err := readBody(pool, bytes.NewReader(body), -1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
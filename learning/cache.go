@@ -0,0 +1,219 @@
+package learning
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached response body
+type CacheEntry struct {
+	Data    []byte    `json:"data"`
+	Expires time.Time `json:"expires"`
+}
+
+// Cache stores response bodies by url
+type Cache interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry) error
+	Delete(key string)
+}
+
+// CachingScraper wraps a Scraper and serves repeated urls from a Cache until
+// their TTL runs out, failed scrapes are never cached
+type CachingScraper struct {
+	Scraper Scraper
+	Cache   Cache
+	TTL     time.Duration
+	// Clock decides when entries expire, RealClock when nil
+	Clock Clock
+	// Logger reports entries that couldn't be cached, slog.Default() when nil
+	Logger Logger
+}
+
+// NewCachingScraper creates a CachingScraper
+func NewCachingScraper(scraper Scraper, cache Cache, ttl time.Duration) *CachingScraper {
+	return &CachingScraper{Scraper: scraper, Cache: cache, TTL: ttl}
+}
+
+// Name identifies the CachingScraper and the scraper it wraps
+func (c *CachingScraper) Name() string {
+	return "cache(" + ScraperName(c.Scraper) + ")"
+}
+
+// Scrape returns the cached body for url or fetches and caches it. A body
+// that can't be cached is still returned, the cache error is only logged.
+func (c *CachingScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	now := c.now()
+	if entry, ok := c.Cache.Get(url); ok {
+		if now.Before(entry.Expires) {
+			return bytes.Clone(entry.Data), nil
+		}
+		c.Cache.Delete(url)
+	}
+
+	data, err := c.Scraper.Scrape(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Cache.Set(url, CacheEntry{Data: bytes.Clone(data), Expires: now.Add(c.TTL)}); err != nil {
+		c.logger().Warn("failed to cache page", "url", url, "error", err)
+	}
+	return data, nil
+}
+
+// Close closes the wrapped scraper
+func (c *CachingScraper) Close() error {
+	return closeScraper(c.Scraper)
+}
+
+func (c *CachingScraper) logger() Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+func (c *CachingScraper) now() time.Time {
+	if c.Clock == nil {
+		return RealClock.Now()
+	}
+	return c.Clock.Now()
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used entry
+// once it holds Capacity entries
+type MemoryCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewMemoryCache creates a MemoryCache holding up to capacity entries
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// Get returns the entry for key and marks it as recently used
+func (m *MemoryCache) Get(key string) (CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true
+}
+
+// Set stores entry under key, evicting the least recently used entry when full
+func (m *MemoryCache) Set(key string, entry CacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		el.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(el)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	if m.capacity > 0 && m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Delete removes key from the cache
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.order.Remove(el)
+		delete(m.entries, key)
+	}
+}
+
+// DiskCache is a Cache that keeps every entry in its own file in Dir,
+// so the cache survives between runs
+type DiskCache struct {
+	Dir string
+}
+
+// NewDiskCache creates a DiskCache in dir, creating the directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+// Get reads the entry for key, unreadable entries count as missing
+func (d *DiskCache) Get(key string) (CacheEntry, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Set writes the entry for key
+func (d *DiskCache) Set(key string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	// write to a temporary file first so readers never see half an entry
+	tmp, err := os.CreateTemp(d.Dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the entry for key
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.Dir, HashName(key)+".json")
+}
@@ -0,0 +1,49 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger keeps the messages logged to it
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) log(level, msg string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprint(level, " ", msg, " ", args))
+}
+
+func (l *recordingLogger) Info(msg string, args ...any)  { l.log("INFO", msg, args...) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.log("WARN", msg, args...) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.log("ERROR", msg, args...) }
+
+func TestCachingScraperSetFails(t *testing.T) {
+	cache, err := NewDiskCache(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every write fails once the directory is gone
+	os.RemoveAll(cache.Dir)
+
+	logger := &recordingLogger{}
+	stub := NewStubScraper(map[string][]byte{"https://example.com/": []byte("page")})
+	c := NewCachingScraper(stub, cache, time.Minute)
+	c.Logger = logger
+
+	data, err := c.Scrape(context.Background(), "https://example.com/")
+	if err != nil || string(data) != "page" {
+		t.Fatalf("Scrape() = %q, %v, want the page", data, err)
+	}
+	if len(logger.messages) != 1 {
+		t.Errorf("logged %q, want the cache error once", logger.messages)
+	}
+}
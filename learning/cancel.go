@@ -0,0 +1,100 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrURLCancelled is the cancellation cause of a url stopped with CancelURL
+var ErrURLCancelled = errors.New("url cancelled")
+
+// CancelURL stops the fetch of url if it is queued or in flight in a running batch,
+// the other urls keep going. A queued url is skipped when a worker picks it up,
+// its result matches ErrURLCancelled. It reports whether anything was cancelled.
+func (c *ConcurrentScraper) CancelURL(url string) bool {
+	cancelled := c.inflight.cancel(url)
+	for _, b := range c.inflight.running() {
+		cancelled = b.cancelQueued(url) || cancelled
+	}
+	return cancelled
+}
+
+// cancelledResult is the result of a queued url cancelled with CancelURL
+func cancelledResult(url string) Result {
+	return Result{URL: url, Err: fmt.Errorf("%w: %w", ErrURLCancelled, context.Canceled)}
+}
+
+// inflightSet tracks the cancel functions of the urls that are being scraped
+// and the batches that are running
+type inflightSet struct {
+	mu      sync.Mutex
+	next    uint64
+	cancels map[string]map[uint64]context.CancelCauseFunc
+	batches map[*batch]bool
+}
+
+// register adds b to the running batches, the returned function removes it again
+func (s *inflightSet) register(b *batch) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.batches == nil {
+		s.batches = map[*batch]bool{}
+	}
+	s.batches[b] = true
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.batches, b)
+	}
+}
+
+func (s *inflightSet) running() []*batch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batches := make([]*batch, 0, len(s.batches))
+	for b := range s.batches {
+		batches = append(batches, b)
+	}
+	return batches
+}
+
+// track registers cancel for url, the returned function removes it again
+func (s *inflightSet) track(url string, cancel context.CancelCauseFunc) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancels == nil {
+		s.cancels = map[string]map[uint64]context.CancelCauseFunc{}
+	}
+	if s.cancels[url] == nil {
+		s.cancels[url] = map[uint64]context.CancelCauseFunc{}
+	}
+	id := s.next
+	s.next++
+	s.cancels[url][id] = cancel
+
+	return func() {
+		cancel(nil)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.cancels[url], id)
+		if len(s.cancels[url]) == 0 {
+			delete(s.cancels, url)
+		}
+	}
+}
+
+func (s *inflightSet) cancel(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, cancel := range s.cancels[url] {
+		cancel(ErrURLCancelled)
+	}
+	return len(s.cancels[url]) > 0
+}
@@ -0,0 +1,67 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCancelURL(t *testing.T) {
+	started := make(chan struct{})
+	var queuedHits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/queued" {
+			queuedHits.Add(1)
+			return
+		}
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	// a single worker keeps /queued waiting behind /inflight
+	c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 1)
+	done := make(chan []Result)
+	go func() {
+		done <- c.Scrape(context.Background(), []string{srv.URL + "/inflight", srv.URL + "/queued"})
+	}()
+	<-started
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"queued", srv.URL + "/queued"},
+		{"in flight", srv.URL + "/inflight"},
+	}
+	for _, tt := range tests {
+		if !c.CancelURL(tt.url) {
+			t.Errorf("%s: CancelURL(%s) = false, want true", tt.name, tt.url)
+		}
+	}
+
+	var results []Result
+	select {
+	case results = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the batch didn't finish after cancelling every url")
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, ErrURLCancelled) {
+			t.Errorf("%s: got error %v, want ErrURLCancelled", result.URL, result.Err)
+		}
+	}
+	if n := queuedHits.Load(); n != 0 {
+		t.Errorf("the cancelled queued url was fetched %d times", n)
+	}
+	if c.CancelURL(srv.URL + "/queued") {
+		t.Error("CancelURL after the batch = true, want false")
+	}
+}
@@ -0,0 +1,91 @@
+package learning
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock tells the time for the time-dependent parts of the scraper, such as rate
+// limiting and cool-downs, so tests can move time forward without sleeping
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is the Clock backed by the time package
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock makes the scraper use clock instead of the real time
+func WithClock(clock Clock) Option {
+	return func(s *SimpleScraper) {
+		s.clock = clock
+	}
+}
+
+// MockClock is a Clock that only moves when Advance is called
+type MockClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []mockWaiter
+}
+
+type mockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewMockClock creates a MockClock set to now
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+// Now returns the mock time
+func (m *MockClock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// After returns a channel that fires once the mock time has advanced by d
+func (m *MockClock) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- m.now
+		return ch
+	}
+	m.waiters = append(m.waiters, mockWaiter{at: m.now.Add(d), ch: ch})
+	return ch
+}
+
+// Sleep blocks until the mock time has advanced by d
+func (m *MockClock) Sleep(d time.Duration) {
+	<-m.After(d)
+}
+
+// Advance moves the mock time forward and fires every After that is due,
+// earliest first, each with the time it was due at
+func (m *MockClock) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = m.now.Add(d)
+	slices.SortStableFunc(m.waiters, func(a, b mockWaiter) int { return a.at.Compare(b.at) })
+	waiting := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.at.After(m.now) {
+			waiting = append(waiting, w)
+			continue
+		}
+		w.ch <- w.at
+	}
+	m.waiters = waiting
+}
@@ -0,0 +1,81 @@
+package learning
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockClockAfter(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMockClock(start)
+
+	if got := <-m.After(0); !got.Equal(start) {
+		t.Errorf("After(0) fired at %v, want right away at %v", got, start)
+	}
+
+	// registered out of order, they must fire by due time
+	delays := []time.Duration{3 * time.Second, time.Second, 2 * time.Second}
+	chans := map[time.Duration]<-chan time.Time{}
+	for _, d := range delays {
+		chans[d] = m.After(d)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		fired   []time.Duration
+	}{
+		{500 * time.Millisecond, nil},
+		{500 * time.Millisecond, []time.Duration{time.Second}},
+		{time.Second, []time.Duration{2 * time.Second}},
+		// jumping past the last one fires it with the time it was due at
+		{time.Minute, []time.Duration{3 * time.Second}},
+	}
+	for _, step := range steps {
+		m.Advance(step.advance)
+		for _, d := range delays {
+			want := false
+			for _, f := range step.fired {
+				want = want || f == d
+			}
+			select {
+			case got := <-chans[d]:
+				if !want {
+					t.Errorf("After(%v) fired at %v, too early", d, m.Now())
+				} else if !got.Equal(start.Add(d)) {
+					t.Errorf("After(%v) fired with %v, want %v", d, got, start.Add(d))
+				}
+			default:
+				if want {
+					t.Errorf("After(%v) didn't fire at %v", d, m.Now())
+				}
+			}
+		}
+	}
+}
+
+func TestMockClockSleep(t *testing.T) {
+	m := NewMockClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	woke := make(chan struct{})
+	go func() {
+		m.Sleep(2 * time.Second)
+		close(woke)
+	}()
+	waitFor(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.waiters) == 1
+	})
+
+	m.Advance(time.Second)
+	select {
+	case <-woke:
+		t.Fatal("Sleep(2s) returned after 1s")
+	case <-time.After(10 * time.Millisecond):
+	}
+	m.Advance(time.Second)
+	select {
+	case <-woke:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Sleep(2s) didn't return after 2s")
+	}
+}
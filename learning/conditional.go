@@ -0,0 +1,133 @@
+package learning
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// ErrNotModified is returned by Scrape when the server answered a conditional
+// request with 304, ScrapeResult flags the Result as NotModified instead
+var ErrNotModified = errors.New("not modified")
+
+// Validators are the response headers that let a later request ask whether a
+// page changed
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ValidatorStore keeps the validators of the pages fetched so far by url
+type ValidatorStore interface {
+	Get(url string) (Validators, bool)
+	Set(url string, v Validators) error
+}
+
+// WithConditionalRequests sends If-None-Match and If-Modified-Since for pages
+// store has validators for, and records the validators of every fetched page.
+// A 304 response gives a Result with NotModified set and no body.
+func WithConditionalRequests(store ValidatorStore) Option {
+	return func(s *SimpleScraper) {
+		s.validators = store
+	}
+}
+
+// setConditional adds the stored validators of url to req, unless the caller
+// already made the request conditional
+func (s *SimpleScraper) setConditional(req *http.Request, url string) {
+	if s.validators == nil || conditional(req) {
+		return
+	}
+	v, ok := s.validators.Get(url)
+	if !ok {
+		return
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// storeValidators records the validators of a successful response
+func (s *SimpleScraper) storeValidators(url string, header http.Header) error {
+	if s.validators == nil {
+		return nil
+	}
+	v := Validators{ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}
+	if v == (Validators{}) {
+		return nil
+	}
+	if err := s.validators.Set(url, v); err != nil {
+		return fmt.Errorf("failed to store validators: %w", err)
+	}
+	return nil
+}
+
+func conditional(req *http.Request) bool {
+	return req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+}
+
+// FileValidatorStore is a ValidatorStore kept in a JSON file so re-scrapes in
+// later runs can be conditional. Set only changes memory, call Save to write the file.
+type FileValidatorStore struct {
+	Path string
+
+	mu         sync.Mutex
+	validators map[string]Validators
+}
+
+// NewFileValidatorStore loads the validators in path, a missing file gives an empty store
+func NewFileValidatorStore(path string) (*FileValidatorStore, error) {
+	f := &FileValidatorStore{Path: path, validators: map[string]Validators{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validators: %w", err)
+	}
+	if err := json.Unmarshal(data, &f.validators); err != nil {
+		return nil, fmt.Errorf("failed to decode validators: %w", err)
+	}
+	return f, nil
+}
+
+// Get returns the validators of url
+func (f *FileValidatorStore) Get(url string) (Validators, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	v, ok := f.validators[url]
+	return v, ok
+}
+
+// Set records the validators of url
+func (f *FileValidatorStore) Set(url string, v Validators) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.validators[url] = v
+	return nil
+}
+
+// Save writes the validators to Path
+func (f *FileValidatorStore) Save() error {
+	f.mu.Lock()
+	data, err := json.MarshalIndent(f.validators, "", "  ")
+	f.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode validators: %w", err)
+	}
+
+	if err := os.WriteFile(f.Path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write validators: %w", err)
+	}
+	return nil
+}
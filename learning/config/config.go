@@ -0,0 +1,347 @@
+// Package config builds scrapers from a YAML or TOML file
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"test/learning"
+)
+
+// Format is the syntax of a config file
+type Format string
+
+const (
+	YAML Format = "yaml"
+	TOML Format = "toml"
+)
+
+// Duration is a time.Duration written as a string such as "10s" or "1m30s"
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config holds the settings of a SimpleScraper, a ConcurrentScraper and a Crawler
+type Config struct {
+	Scraper Scraper `yaml:"scraper" toml:"scraper"`
+	Batch   Batch   `yaml:"batch" toml:"batch"`
+	Crawl   Crawl   `yaml:"crawl" toml:"crawl"`
+}
+
+// Scraper configures the SimpleScraper
+type Scraper struct {
+	Timeout   Duration          `yaml:"timeout" toml:"timeout"`
+	UserAgent string            `yaml:"user_agent" toml:"user_agent"`
+	Headers   map[string]string `yaml:"headers" toml:"headers"`
+	// Retries is the number of retries after a failed request
+	Retries    int      `yaml:"retries" toml:"retries"`
+	RetryDelay Duration `yaml:"retry_delay" toml:"retry_delay"`
+	// MaxBodyBytes fails responses with a bigger body, zero means no limit
+	MaxBodyBytes int64    `yaml:"max_body_bytes" toml:"max_body_bytes"`
+	ContentTypes []string `yaml:"content_types" toml:"content_types"`
+	// MaxRedirects is the number of redirects followed, -1 follows none
+	MaxRedirects int `yaml:"max_redirects" toml:"max_redirects"`
+	// HTTPVersion is "auto", "1.1", "2" or "3"
+	HTTPVersion string `yaml:"http_version" toml:"http_version"`
+	TLS         TLS    `yaml:"tls" toml:"tls"`
+}
+
+// TLS configures the TLS connections of the scraper
+type TLS struct {
+	// RootCAs are PEM files with certificates trusted next to the system roots
+	RootCAs []string `yaml:"root_cas" toml:"root_cas"`
+	// MinVersion is "1.0", "1.1", "1.2" or "1.3"
+	MinVersion         string `yaml:"min_version" toml:"min_version"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" toml:"insecure_skip_verify"`
+}
+
+// Batch configures the ConcurrentScraper
+type Batch struct {
+	Workers int `yaml:"workers" toml:"workers"`
+	// MaxInflight allows more requests in flight than there are workers
+	MaxInflight int `yaml:"max_inflight" toml:"max_inflight"`
+	// MaxPerHost caps the requests in flight per host, zero means no cap
+	MaxPerHost    int   `yaml:"max_per_host" toml:"max_per_host"`
+	FailFast      bool  `yaml:"fail_fast" toml:"fail_fast"`
+	MaxTotalBytes int64 `yaml:"max_total_bytes" toml:"max_total_bytes"`
+}
+
+// Crawl configures the Crawler
+type Crawl struct {
+	MaxDepth int `yaml:"max_depth" toml:"max_depth"`
+	MaxPages int `yaml:"max_pages" toml:"max_pages"`
+	// MaxPerDomain caps the pages fetched from one host, zero means no limit
+	MaxPerDomain int `yaml:"max_per_domain" toml:"max_per_domain"`
+	// AllowedDomains limits the crawl to these domains and their subdomains,
+	// empty allows every domain
+	AllowedDomains []string `yaml:"allowed_domains" toml:"allowed_domains"`
+	// BlockedDomains are host rules that are never crawled: exact hosts,
+	// wildcards such as "*.example.com" or regular expressions between slashes
+	BlockedDomains []string `yaml:"blocked_domains" toml:"blocked_domains"`
+}
+
+// Default returns the settings used for everything a file leaves out
+func Default() Config {
+	return Config{
+		Scraper: Scraper{
+			Timeout:     Duration(10 * time.Second),
+			RetryDelay:  Duration(500 * time.Millisecond),
+			HTTPVersion: "auto",
+		},
+		Batch: Batch{Workers: 5},
+		Crawl: Crawl{MaxDepth: 2, MaxPages: 100},
+	}
+}
+
+// Load reads the config file at path, its extension picks the format
+func Load(path string) (*Config, error) {
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = YAML
+	case ".toml":
+		format = TOML
+	default:
+		return nil, fmt.Errorf("unknown config format of %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads a config in format on top of the defaults and validates it.
+// Unknown keys are an error, they are usually typos.
+func Parse(data []byte, format Format) (*Config, error) {
+	cfg := Default()
+	switch format {
+	case YAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// an empty file leaves the defaults
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse yaml: %w", err)
+		}
+	case TOML:
+		meta, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse toml: %w", err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate reports every invalid setting
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Scraper.Timeout <= 0 {
+		errs = append(errs, errors.New("scraper.timeout must be positive"))
+	}
+	if c.Scraper.Retries < 0 {
+		errs = append(errs, errors.New("scraper.retries can't be negative"))
+	}
+	if c.Scraper.MaxBodyBytes < 0 {
+		errs = append(errs, errors.New("scraper.max_body_bytes can't be negative"))
+	}
+	if c.Scraper.MaxRedirects < -1 {
+		errs = append(errs, errors.New("scraper.max_redirects must be -1 or more"))
+	}
+	if _, err := httpVersion(c.Scraper.HTTPVersion); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := tlsVersion(c.Scraper.TLS.MinVersion); err != nil {
+		errs = append(errs, err)
+	}
+	for name := range c.Scraper.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			errs = append(errs, fmt.Errorf("invalid header name %q", name))
+		}
+	}
+	if c.Batch.Workers < 1 {
+		errs = append(errs, errors.New("batch.workers must be at least 1"))
+	}
+	if c.Batch.MaxInflight < 0 || c.Batch.MaxPerHost < 0 || c.Batch.MaxTotalBytes < 0 {
+		errs = append(errs, errors.New("batch limits can't be negative"))
+	}
+	if c.Crawl.MaxDepth < 0 || c.Crawl.MaxPages < 0 || c.Crawl.MaxPerDomain < 0 {
+		errs = append(errs, errors.New("crawl limits can't be negative"))
+	}
+	for _, domain := range c.Crawl.AllowedDomains {
+		if domain == "" || strings.ContainsAny(domain, "/: *?[") {
+			errs = append(errs, fmt.Errorf("invalid allowed domain %q", domain))
+		}
+	}
+	for _, rule := range c.Crawl.BlockedDomains {
+		if _, err := learning.ParseHostRule(rule); err != nil {
+			errs = append(errs, fmt.Errorf("invalid blocked domain: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewScraper creates the SimpleScraper described by the config, followed by opts
+func (c *Config) NewScraper(opts ...learning.Option) (*learning.SimpleScraper, error) {
+	s := c.Scraper
+	header := http.Header{}
+	for name, value := range s.Headers {
+		header.Set(name, value)
+	}
+	if s.UserAgent != "" {
+		header.Set("User-Agent", s.UserAgent)
+	}
+
+	version, err := httpVersion(s.HTTPVersion)
+	if err != nil {
+		return nil, err
+	}
+	all := []learning.Option{learning.WithHeaders(header), learning.WithHTTPVersion(version)}
+	if s.Retries > 0 {
+		all = append(all, learning.WithRetry(learning.RetryPolicy{
+			MaxAttempts:  s.Retries + 1,
+			InitialDelay: time.Duration(s.RetryDelay),
+			Factor:       2,
+			Jitter:       0.2,
+		}))
+	}
+	if s.MaxBodyBytes > 0 {
+		all = append(all, learning.WithMaxBodyBytes(s.MaxBodyBytes))
+	}
+	if len(s.ContentTypes) > 0 {
+		all = append(all, learning.WithContentTypes(s.ContentTypes...))
+	}
+	switch {
+	case s.MaxRedirects < 0:
+		all = append(all, learning.WithoutRedirects())
+	case s.MaxRedirects > 0:
+		all = append(all, learning.WithMaxRedirects(s.MaxRedirects))
+	}
+
+	if len(s.TLS.RootCAs) > 0 {
+		pool, err := learning.LoadCertPool(s.TLS.RootCAs...)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, learning.WithRootCAs(pool))
+	}
+	minVersion, err := tlsVersion(s.TLS.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	if minVersion != 0 {
+		all = append(all, learning.WithMinTLSVersion(minVersion))
+	}
+	if s.TLS.InsecureSkipVerify {
+		all = append(all, learning.WithInsecureSkipVerify())
+	}
+
+	return learning.NewSimpleScraper(time.Duration(s.Timeout), append(all, opts...)...), nil
+}
+
+// NewConcurrentScraper creates the ConcurrentScraper described by the config
+// around scraper, followed by opts
+func (c *Config) NewConcurrentScraper(scraper learning.Scraper, opts ...learning.ConcurrentOption) *learning.ConcurrentScraper {
+	b := c.Batch
+	var all []learning.ConcurrentOption
+	if b.MaxInflight > 0 {
+		all = append(all, learning.WithMaxInflight(b.MaxInflight))
+	}
+	if b.MaxPerHost > 0 {
+		all = append(all, learning.WithMaxPerHost(b.MaxPerHost))
+	}
+	if b.FailFast {
+		all = append(all, learning.WithFailFast())
+	}
+	if b.MaxTotalBytes > 0 {
+		all = append(all, learning.WithMaxTotalBytes(b.MaxTotalBytes))
+	}
+	return learning.NewConcurrentScraper(scraper, b.Workers, append(all, opts...)...)
+}
+
+// NewCrawler creates the Crawler described by the config around scraper
+func (c *Config) NewCrawler(scraper learning.Scraper) (*learning.Crawler, error) {
+	crawler := learning.NewCrawler(scraper, c.Batch.Workers, c.Crawl.MaxDepth, c.Crawl.MaxPages)
+	crawler.MaxPerDomain = c.Crawl.MaxPerDomain
+	if len(c.Crawl.AllowedDomains) > 0 || len(c.Crawl.BlockedDomains) > 0 {
+		// an allowed domain covers its subdomains too
+		var include []string
+		for _, domain := range c.Crawl.AllowedDomains {
+			domain = strings.TrimPrefix(domain, ".")
+			include = append(include, domain, "*."+domain)
+		}
+		filter, err := learning.NewDomainFilter(include, c.Crawl.BlockedDomains)
+		if err != nil {
+			return nil, err
+		}
+		crawler.Domains = filter
+	}
+	return crawler, nil
+}
+
+func httpVersion(v string) (learning.HTTPVersion, error) {
+	switch v {
+	case "", "auto":
+		return learning.HTTPAuto, nil
+	case "1", "1.1":
+		return learning.HTTP1, nil
+	case "2":
+		return learning.HTTP2, nil
+	case "3":
+		return learning.HTTP3, nil
+	}
+	return 0, fmt.Errorf("unknown scraper.http_version %q", v)
+}
+
+func tlsVersion(v string) (uint16, error) {
+	switch v {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown scraper.tls.min_version %q", v)
+}
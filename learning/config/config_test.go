@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	yamlConfig := `
+scraper:
+  timeout: 3s
+  retries: 2
+batch:
+  workers: 8
+crawl:
+  max_depth: 4
+  allowed_domains: [example.com]
+`
+	tomlConfig := `
+[scraper]
+timeout = "3s"
+retries = 2
+
+[batch]
+workers = 8
+
+[crawl]
+max_depth = 4
+allowed_domains = ["example.com"]
+`
+	for format, data := range map[Format]string{YAML: yamlConfig, TOML: tomlConfig} {
+		t.Run(string(format), func(t *testing.T) {
+			c, err := Parse([]byte(data), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if time.Duration(c.Scraper.Timeout) != 3*time.Second || c.Scraper.Retries != 2 ||
+				c.Batch.Workers != 8 || c.Crawl.MaxDepth != 4 {
+				t.Errorf("got %+v", c)
+			}
+			// fields the file leaves out keep their defaults
+			if c.Crawl.MaxPages != Default().Crawl.MaxPages {
+				t.Errorf("MaxPages = %d, want the default %d", c.Crawl.MaxPages, Default().Crawl.MaxPages)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unknown field", "scraper:\n  timout: 3s\n", "timout"},
+		{"bad duration", "scraper:\n  timeout: soon\n", "soon"},
+		{"no workers", "batch:\n  workers: 0\n", "batch.workers"},
+		{"negative limit", "crawl:\n  max_per_domain: -1\n", "crawl limits"},
+		{"bad allowed domain", "crawl:\n  allowed_domains: ['*.example.com']\n", "allowed domain"},
+		{"bad blocked domain", "crawl:\n  blocked_domains: ['/(/']\n", "blocked domain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml), YAML)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCrawlerDomains(t *testing.T) {
+	c, err := Parse([]byte("crawl:\n  allowed_domains: [example.com]\n  blocked_domains: ['ads.example.com']\n"), YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler, err := c.NewCrawler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"https://example.com/":     true,
+		"https://www.example.com/": true,
+		"https://ads.example.com/": false,
+		"https://example.org/":     false,
+	}
+	for url, want := range tests {
+		if got := crawler.Domains.Check(url) == nil; got != want {
+			t.Errorf("%s allowed = %v, want %v", url, got, want)
+		}
+	}
+}
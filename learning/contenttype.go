@@ -0,0 +1,91 @@
+package learning
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ContentTypeError is returned for responses whose content type isn't allowed,
+// it matches ErrSkipped so the url counts as skipped rather than failed
+type ContentTypeError struct {
+	URL         string
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("%s: content type %q of %s is not allowed", ErrSkipped, e.ContentType, e.URL)
+}
+
+// Is makes errors.Is(err, ErrSkipped) hold
+func (e *ContentTypeError) Is(target error) bool {
+	return target == ErrSkipped
+}
+
+// WithContentTypes only accepts responses with one of types, such as "text/html"
+// or "text/*", other responses fail with a ContentTypeError without reading the body
+func WithContentTypes(types ...string) Option {
+	return func(s *SimpleScraper) {
+		s.contentTypes = types
+	}
+}
+
+// WithContentSniffing detects the content type from the start of the body when
+// the response has none or only claims application/octet-stream
+func WithContentSniffing() Option {
+	return func(s *SimpleScraper) {
+		s.sniff = true
+	}
+}
+
+// checkContentType applies the content type allowlist to resp, sniffing
+// replaces resp.Body with a reader that still returns the peeked bytes
+func (s *SimpleScraper) checkContentType(resp *http.Response, url string) error {
+	if len(s.contentTypes) == 0 {
+		return nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if s.sniff && (mediaType == "" || mediaType == "application/octet-stream") {
+		br := bufio.NewReaderSize(resp.Body, 512)
+		// a short body gives an error here, whatever was read is still sniffed
+		head, _ := br.Peek(512)
+		contentType = http.DetectContentType(head)
+		mediaType, _, _ = mime.ParseMediaType(contentType)
+		resp.Body = &peekedBody{Reader: br, body: resp.Body}
+	}
+
+	if !allowedContentType(mediaType, s.contentTypes) {
+		return &ContentTypeError{URL: url, ContentType: contentType}
+	}
+	return nil
+}
+
+// allowedContentType matches mediaType against types, "type/*" allows every subtype
+func allowedContentType(mediaType string, types []string) bool {
+	mediaType = strings.ToLower(mediaType)
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// peekedBody reads through the buffered reader used for sniffing and closes the original body
+type peekedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (p *peekedBody) Close() error {
+	return p.body.Close()
+}
@@ -0,0 +1,246 @@
+package learning
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/net/html"
+)
+
+// Page is a crawled page and the depth it was found at, seeds have depth 0
+type Page struct {
+	Result
+	Depth int
+	// Seed is the seed the page was first reached from
+	Seed string
+}
+
+// StopReason tells why a crawl stopped
+type StopReason string
+
+const (
+	// StopDone means every link the crawl was allowed to follow was fetched
+	StopDone StopReason = "done"
+	// StopCancelled means the context was cancelled before the crawl was done
+	StopCancelled StopReason = "cancelled"
+	// StopMaxPages means links were left unfetched because of MaxPages
+	StopMaxPages StopReason = "max pages"
+	// StopMaxDepth means the pages at MaxDepth had links that weren't followed
+	StopMaxDepth StopReason = "max depth"
+	// StopMaxPerDomain means links were left unfetched because of MaxPerDomain
+	StopMaxPerDomain StopReason = "max per domain"
+)
+
+// CrawlResult is the outcome of a crawl
+type CrawlResult struct {
+	Pages []Page
+	// Filtered holds the links Domains rejected, each once with a *FilteredError
+	// and the depth it would have been fetched at
+	Filtered []Page
+	// Stopped is the reason the crawl stopped, when several limits were hit it
+	// is the first of cancelled, max pages, max depth and max per domain
+	Stopped StopReason
+}
+
+// Crawler follows the links of fetched pages, breadth first, up to MaxDepth
+// levels and MaxPages pages. Every url is fetched once.
+type Crawler struct {
+	Scraper    Scraper
+	NumWorkers int
+	MaxDepth   int
+	// MaxPages caps the number of pages fetched, zero means no limit
+	MaxPages int
+	// MaxPerDomain caps the number of pages fetched from one host, zero means no limit
+	MaxPerDomain int
+	// Allow reports whether a link is followed, nil follows every link
+	Allow func(url string) bool
+	// Domains decides which hosts are crawled, unlike Allow the links it
+	// rejects are reported in CrawlResult.Filtered
+	Domains *DomainFilter
+	// Visited remembers the fetched urls, every Crawl starts a new exact set
+	// when nil. NewBloomDeduper fits crawls of millions of urls in little
+	// memory. A Visited shared between crawls skips what earlier ones fetched.
+	Visited *Deduper
+}
+
+// NewCrawler creates a new Crawler
+func NewCrawler(scraper Scraper, numWorkers, maxDepth, maxPages int) *Crawler {
+	return &Crawler{Scraper: scraper, NumWorkers: numWorkers, MaxDepth: maxDepth, MaxPages: maxPages}
+}
+
+// Crawl fetches seed and the pages it links to. Every level is fetched with
+// the same ctx, so cancelling it stops the crawl at any depth.
+func (c *Crawler) Crawl(ctx context.Context, seed string) []Page {
+	return c.Run(ctx, seed).Pages
+}
+
+// Run crawls like Crawl and also reports which limit, if any, stopped the crawl
+func (c *Crawler) Run(ctx context.Context, seed string) CrawlResult {
+	return c.RunSeeds(ctx, []string{seed})
+}
+
+// CrawlSeeds fetches every seed and the pages they link to in one crawl, a
+// page reachable from several seeds is fetched once
+func (c *Crawler) CrawlSeeds(ctx context.Context, seeds []string) []Page {
+	return c.RunSeeds(ctx, seeds).Pages
+}
+
+// RunSeeds crawls like CrawlSeeds and also reports which limit, if any,
+// stopped the crawl. The seeds share the limits and every Page records the
+// seed it was reached from.
+func (c *Crawler) RunSeeds(ctx context.Context, seeds []string) CrawlResult {
+	scraper := NewConcurrentScraper(c.Scraper, c.NumWorkers)
+	st := &crawlState{visited: c.Visited, perDomain: map[string]int{}, filtered: map[string]bool{}, seeds: map[string]string{}}
+	if st.visited == nil {
+		st.visited = NewDeduper()
+	}
+
+	var level []string
+	for _, seed := range seeds {
+		level = append(level, c.unvisited(st, []string{seed}, 0, seed)...)
+	}
+
+	var pages []Page
+	for depth := 0; len(level) > 0 && ctx.Err() == nil; depth++ {
+		var next []string
+		for _, result := range scraper.Scrape(ctx, level) {
+			seed := st.seeds[result.URL]
+			pages = append(pages, Page{Result: result, Depth: depth, Seed: seed})
+			if result.Err != nil {
+				continue
+			}
+			links, err := ExtractLinks(result.URL, result.Data)
+			if err != nil {
+				continue
+			}
+			if depth >= c.MaxDepth {
+				st.maxDepth = st.maxDepth || c.anyUnvisited(st, links)
+				continue
+			}
+			next = append(next, c.unvisited(st, links, depth+1, seed)...)
+		}
+		level = next
+	}
+	return CrawlResult{Pages: pages, Filtered: st.rejected, Stopped: st.reason(ctx)}
+}
+
+// crawlState is what a single crawl keeps track of
+type crawlState struct {
+	visited *Deduper
+	// pages counts the urls this crawl scheduled, visited may hold earlier crawls' too
+	pages     int
+	perDomain map[string]int
+	filtered  map[string]bool
+	rejected  []Page
+	// seeds maps every scheduled url to the seed it was reached from
+	seeds map[string]string
+
+	// the limits that left urls unfetched
+	maxPages, maxDepth, maxPerDomain bool
+}
+
+// reason returns the StopReason for the limits st hit
+func (st *crawlState) reason(ctx context.Context) StopReason {
+	switch {
+	case ctx.Err() != nil:
+		return StopCancelled
+	case st.maxPages:
+		return StopMaxPages
+	case st.maxDepth:
+		return StopMaxDepth
+	case st.maxPerDomain:
+		return StopMaxPerDomain
+	}
+	return StopDone
+}
+
+// unvisited marks and returns the urls not seen before, without scheduling
+// more than MaxPages urls in total or MaxPerDomain urls of a host. The urls
+// Domains rejects are recorded as filtered at depth. The urls are reached from seed.
+func (c *Crawler) unvisited(st *crawlState, urls []string, depth int, seed string) []string {
+	var fresh []string
+	for _, u := range urls {
+		if c.Allow != nil && !c.Allow(u) {
+			continue
+		}
+		if err := c.Domains.Check(u); err != nil {
+			if !st.filtered[u] {
+				st.filtered[u] = true
+				st.rejected = append(st.rejected, Page{Result: Result{URL: u, Err: err}, Depth: depth, Seed: seed})
+			}
+			continue
+		}
+		if st.visited.Seen(u) {
+			continue
+		}
+		if c.MaxPages > 0 && st.pages >= c.MaxPages {
+			st.maxPages = true
+			break
+		}
+		host := hostOf(u)
+		if c.MaxPerDomain > 0 && st.perDomain[host] >= c.MaxPerDomain {
+			st.maxPerDomain = true
+			continue
+		}
+		if st.visited.Add(u) {
+			st.pages++
+			st.perDomain[host]++
+			st.seeds[u] = seed
+			fresh = append(fresh, u)
+		}
+	}
+	return fresh
+}
+
+// anyUnvisited reports whether a link of urls would have been followed if the
+// crawl went one level deeper
+func (c *Crawler) anyUnvisited(st *crawlState, urls []string) bool {
+	for _, u := range urls {
+		if (c.Allow == nil || c.Allow(u)) && c.Domains.Check(u) == nil && !st.visited.Seen(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractLinks returns the http and https links of an html page, resolved
+// against the page url and without fragments
+func ExtractLinks(pageURL string, data []byte) ([]string, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var links []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			for _, attr := range n.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				ref, err := base.Parse(attr.Val)
+				if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+					continue
+				}
+				ref.Fragment = ""
+				if link := ref.String(); !seen[link] {
+					seen[link] = true
+					links = append(links, link)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links, nil
+}
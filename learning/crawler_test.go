@@ -0,0 +1,121 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// treeServer serves a binary tree of pages, /n links to /2n+1 and /2n+2
+func treeServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Path, "/%d", &n)
+		fmt.Fprintf(w, `<a href="/%d">left</a><a href="/%d">right</a>`, 2*n+1, 2*n+2)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlerLimits(t *testing.T) {
+	srv := treeServer(t)
+	tests := []struct {
+		name      string
+		crawler   Crawler
+		wantPages int
+		wantStop  StopReason
+	}{
+		{"max depth", Crawler{MaxDepth: 2}, 7, StopMaxDepth},
+		{"max pages", Crawler{MaxDepth: 10, MaxPages: 5}, 5, StopMaxPages},
+		{"max per domain", Crawler{MaxDepth: 10, MaxPerDomain: 4}, 4, StopMaxPerDomain},
+		{"max pages before depth", Crawler{MaxDepth: 1, MaxPages: 2}, 2, StopMaxPages},
+		{"done", Crawler{MaxDepth: 10, Allow: func(url string) bool {
+			// only /0 to /9
+			return len(url) <= len(srv.URL)+2
+		}}, 10, StopDone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.crawler
+			c.Scraper = NewSimpleScraper(5 * time.Second)
+			c.NumWorkers = 3
+
+			res := c.Run(context.Background(), srv.URL+"/0")
+			if len(res.Pages) != tt.wantPages {
+				t.Errorf("got %d pages, want %d", len(res.Pages), tt.wantPages)
+			}
+			if res.Stopped != tt.wantStop {
+				t.Errorf("stopped because of %q, want %q", res.Stopped, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestCrawlerSharedVisited(t *testing.T) {
+	srv := treeServer(t)
+	c := NewCrawler(NewSimpleScraper(5*time.Second), 3, 10, 3)
+	c.Visited = NewDeduper()
+
+	first := c.Run(context.Background(), srv.URL+"/0")
+	// the second crawl skips what the first fetched but still gets its own MaxPages
+	second := c.Run(context.Background(), srv.URL+"/3")
+	if len(first.Pages) != 3 || len(second.Pages) != 3 {
+		t.Fatalf("got %d and %d pages, want 3 each", len(first.Pages), len(second.Pages))
+	}
+	for _, p := range second.Pages {
+		for _, q := range first.Pages {
+			if p.URL == q.URL {
+				t.Errorf("%s was fetched by both crawls", p.URL)
+			}
+		}
+	}
+}
+
+func TestCrawlerCancelled(t *testing.T) {
+	srv := treeServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := NewCrawler(NewSimpleScraper(5*time.Second), 2, 3, 0).Run(ctx, srv.URL)
+	if res.Stopped != StopCancelled {
+		t.Errorf("stopped because of %q, want %q", res.Stopped, StopCancelled)
+	}
+}
+
+func TestCrawlerSeeds(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	tree := treeServer(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		tree.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	// /1 is a seed and also linked from /0, /0 links to /1 and /2
+	c := NewCrawler(NewSimpleScraper(5*time.Second), 3, 1, 0)
+	res := c.RunSeeds(context.Background(), []string{srv.URL + "/0", srv.URL + "/1"})
+
+	want := map[string]string{"/0": "/0", "/1": "/1", "/2": "/0", "/3": "/1", "/4": "/1"}
+	if len(res.Pages) != len(want) {
+		t.Fatalf("got %d pages, want %d", len(res.Pages), len(want))
+	}
+	for _, p := range res.Pages {
+		path := strings.TrimPrefix(p.URL, srv.URL)
+		if seed := srv.URL + want[path]; p.Seed != seed {
+			t.Errorf("%s came from seed %s, want %s", path, p.Seed, seed)
+		}
+	}
+	for path, n := range hits {
+		if n != 1 {
+			t.Errorf("%s was fetched %d times, want once", path, n)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package learning
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding lists the encodings SimpleScraper can decode
+const acceptEncoding = "gzip, deflate, br"
+
+// decodeBody replaces the body of resp with a reader that decompresses it
+// according to its Content-Encoding. Stacked encodings such as "gzip, br" are
+// undone in reverse order. Bodies that are empty, like the ones of HEAD
+// requests, are left alone.
+func decodeBody(resp *http.Response) error {
+	var encodings []string
+	for _, encoding := range strings.Split(resp.Header.Get("Content-Encoding"), ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != "" && encoding != "identity" {
+			encodings = append(encodings, encoding)
+		}
+	}
+	if len(encodings) == 0 || resp.ContentLength == 0 ||
+		(resp.Request != nil && resp.Request.Method == http.MethodHead) {
+		return nil
+	}
+
+	// an empty body with an unknown length has no header to decode either
+	br := bufio.NewReader(resp.Body)
+	if _, err := br.Peek(1); err == io.EOF {
+		return nil
+	}
+
+	var r io.Reader = br
+	var closers []io.Closer
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encodings[i] {
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				return fmt.Errorf("failed to decode gzip body: %w", err)
+			}
+			r = zr
+			closers = append(closers, zr)
+		case "deflate":
+			r = deflateReader(r)
+			if c, ok := r.(io.Closer); ok {
+				closers = append(closers, c)
+			}
+		case "br":
+			r = brotli.NewReader(r)
+		default:
+			return fmt.Errorf("unsupported content encoding %q", encodings[i])
+		}
+	}
+
+	resp.Body = &decodedBody{Reader: r, decoders: closers, body: resp.Body}
+	// like net/http does for gzip, the headers now describe the decoded body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// deflateReader handles both zlib wrapped deflate, which the spec asks for,
+// and the raw deflate streams some servers send instead
+func deflateReader(body io.Reader) io.Reader {
+	br := bufio.NewReader(body)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		if zr, err := zlib.NewReader(br); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(br)
+}
+
+// decodedBody reads the decoded stream and closes the decoders and the original body
+type decodedBody struct {
+	io.Reader
+	decoders []io.Closer
+	body     io.ReadCloser
+}
+
+func (d *decodedBody) Close() error {
+	for _, c := range d.decoders {
+		c.Close()
+	}
+	return d.body.Close()
+}
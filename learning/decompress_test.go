@@ -0,0 +1,132 @@
+package learning
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	page := []byte("<html>hello</html>")
+	tests := []struct {
+		name     string
+		method   string
+		encoding string
+		body     []byte
+		want     []byte
+		wantErr  bool
+	}{
+		{name: "none", body: page, want: page},
+		{name: "identity", encoding: "identity", body: page, want: page},
+		{name: "gzip", encoding: "gzip", body: compress(t, "gzip", page), want: page},
+		{name: "x-gzip", encoding: "X-Gzip", body: compress(t, "gzip", page), want: page},
+		{name: "zlib deflate", encoding: "deflate", body: compress(t, "zlib", page), want: page},
+		{name: "raw deflate", encoding: "deflate", body: compress(t, "flate", page), want: page},
+		{name: "brotli", encoding: "br", body: compress(t, "br", page), want: page},
+		// gzip was applied first, so br is undone first
+		{name: "stacked", encoding: "gzip, br", body: compress(t, "br", compress(t, "gzip", page)), want: page},
+		{name: "empty gzip", encoding: "gzip", body: nil, want: nil},
+		{name: "head", method: http.MethodHead, encoding: "gzip", body: nil, want: nil},
+		{name: "unsupported", encoding: "zstd", body: page, wantErr: true},
+		{name: "unsupported stacked", encoding: "gzip, zstd", body: page, wantErr: true},
+		{name: "corrupt gzip", encoding: "gzip", body: page, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			resp := &http.Response{
+				Header:        http.Header{},
+				Body:          io.NopCloser(bytes.NewReader(tt.body)),
+				ContentLength: -1,
+				Request:       &http.Request{Method: method},
+			}
+			if tt.encoding != "" {
+				resp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			err := decodeBody(resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBody() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("decoded body = %q, want %q", got, tt.want)
+			}
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestRawBody(t *testing.T) {
+	page := []byte("<html>hello</html>")
+	gzipped := compress(t, "gzip", page)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipped)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		want         []byte
+		wantEncoding string
+	}{
+		{"decoded", nil, page, ""},
+		{"raw", []Option{WithRawBody()}, gzipped, "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewSimpleScraper(5*time.Second, tt.opts...).ScrapeResult(context.Background(), srv.URL)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if !bytes.Equal(result.Data, tt.want) {
+				t.Errorf("body = %q, want %q", result.Data, tt.want)
+			}
+			// the header only tells the encoding of a body that is still encoded
+			if got := result.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// ErrConnectTimeout is returned when a connection couldn't be established in
+// time, it wraps ErrTimeout
+var ErrConnectTimeout = fmt.Errorf("connection %w", ErrTimeout)
+
+// WithDialTimeout limits how long establishing a connection may take, separate
+// from the client timeout that covers the whole request
+func WithDialTimeout(d time.Duration) Option {
+	return func(s *SimpleScraper) {
+		s.dialer.Timeout = d
+	}
+}
+
+// WithResolver makes the dialer look host names up with resolver, for example one
+// that asks a specific DNS server. Lookups are cancelled with the request context.
+func WithResolver(resolver *net.Resolver) Option {
+	return func(s *SimpleScraper) {
+		s.dialer.Resolver = resolver
+	}
+}
+
+// dialContext dials with the scraper's dialer, or its proxy when one is set,
+// and marks timeouts as ErrConnectTimeout
+func (s *SimpleScraper) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dial := s.dialer.DialContext
+	if s.dial != nil {
+		dial = s.dial
+	} else if s.dns != nil {
+		dial = s.dialCached
+	}
+
+	conn, err := dial(ctx, network, addr)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return nil, fmt.Errorf("%w: %w", ErrConnectTimeout, err)
+	}
+	return conn, err
+}
+
+// Auth holds the username and password for a SOCKS5 proxy
+type Auth = proxy.Auth
+
+// WithSOCKS5Proxy routes every connection through the SOCKS5 proxy at addr,
+// auth may be nil for proxies without authentication
+func WithSOCKS5Proxy(addr string, auth *Auth) Option {
+	return func(s *SimpleScraper) {
+		// the proxy dials through s.dialer so dial timeouts and resolvers still apply
+		d, err := proxy.SOCKS5("tcp", addr, auth, s.dialer)
+		if err != nil {
+			s.dial = func(context.Context, string, string) (net.Conn, error) {
+				return nil, fmt.Errorf("invalid socks5 proxy %s: %w", addr, err)
+			}
+			return
+		}
+		s.dial = d.(proxy.ContextDialer).DialContext
+	}
+}
@@ -0,0 +1,281 @@
+package learning
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// unroutable is a private address nothing answers on, dials to it hang until they time out
+const unroutable = "10.255.255.1:80"
+
+func TestDialTimeout(t *testing.T) {
+	// a resolver that never answers hangs the dial on any network
+	hanging := &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	tests := []struct {
+		name string
+		url  string
+		opts []Option
+	}{
+		{"hanging resolver", "http://example.invalid/", []Option{WithResolver(hanging)}},
+		{"unroutable address", "http://" + unroutable + "/", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts == nil {
+				skipIfRoutable(t)
+			}
+
+			// the client timeout is far longer, only the dial timeout can end the request this soon
+			s := NewSimpleScraper(time.Minute, append(tt.opts, WithDialTimeout(100*time.Millisecond))...)
+			start := time.Now()
+			result := s.ScrapeResult(context.Background(), tt.url)
+			if !errors.Is(result.Err, ErrConnectTimeout) || !errors.Is(result.Err, ErrTimeout) {
+				t.Errorf("got error %v, want ErrConnectTimeout", result.Err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("the request took %v, the dial timeout wasn't applied", elapsed)
+			}
+		})
+	}
+}
+
+// skipIfRoutable skips the test when dials to unroutable don't hang on this network
+func skipIfRoutable(t *testing.T) {
+	t.Helper()
+	if conn, err := net.DialTimeout("tcp", unroutable, 100*time.Millisecond); err == nil {
+		conn.Close()
+		t.Skip("this network answers on unroutable addresses")
+	} else if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Skipf("dialing %s fails without a timeout: %v", unroutable, err)
+	}
+}
+
+// socks5Server is a minimal SOCKS5 proxy for tests, it only supports CONNECT
+// and checks the username and password when user is set
+type socks5Server struct {
+	ln             net.Listener
+	user, password string
+	connects       atomic.Int32
+}
+
+func newSOCKS5Server(t *testing.T, user, password string) *socks5Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &socks5Server{ln: ln, user: user, password: password}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socks5Server) serve(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, 512)
+
+	// greeting: version, methods
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	if s.user == "" {
+		conn.Write([]byte{5, 0})
+	} else {
+		conn.Write([]byte{5, 2})
+		// username/password: version, ulen, user, plen, password
+		io.ReadFull(conn, buf[:2])
+		user := make([]byte, buf[1])
+		io.ReadFull(conn, user)
+		io.ReadFull(conn, buf[:1])
+		password := make([]byte, buf[0])
+		io.ReadFull(conn, password)
+		if string(user) != s.user || string(password) != s.password {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+	}
+
+	// request: version, connect, reserved, address type, address, port
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		io.ReadFull(conn, buf[:4])
+		host = net.IP(buf[:4]).String()
+	case 3:
+		io.ReadFull(conn, buf[:1])
+		name := make([]byte, buf[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	io.ReadFull(conn, buf[:2])
+	port := binary.BigEndian.Uint16(buf[:2])
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	s.connects.Add(1)
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		user      string
+		auth      *Auth
+		wantErr   bool
+		wantProxy bool
+	}{
+		{"open", "", nil, false, true},
+		{"auth", "alice", &Auth{User: "alice", Password: "secret"}, false, true},
+		{"wrong password", "alice", &Auth{User: "alice", Password: "nope"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newSOCKS5Server(t, tt.user, "secret")
+			s := NewSimpleScraper(5*time.Second, WithSOCKS5Proxy(proxy.ln.Addr().String(), tt.auth))
+			defer s.Close()
+
+			data, err := s.Scrape(context.Background(), srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scrape() = %q, %v, want error %v", data, err, tt.wantErr)
+			}
+			if err == nil && string(data) != "page" {
+				t.Errorf("Scrape() = %q, want the page", data)
+			}
+			if got := proxy.connects.Load() > 0; got != tt.wantProxy {
+				t.Errorf("went through the proxy = %v, want %v", got, tt.wantProxy)
+			}
+		})
+	}
+}
+
+// fakeResolver answers every A query with 127.0.0.1 and counts the queries
+func fakeResolver(queries *atomic.Int32) *net.Resolver {
+	return &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveDNS(server, queries)
+		return client, nil
+	}}
+}
+
+// serveDNS answers the length prefixed queries the resolver sends over a stream
+func serveDNS(conn net.Conn, queries *atomic.Int32) {
+	defer conn.Close()
+	for {
+		var size [2]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+
+		var p dnsmessage.Parser
+		header, err := p.Start(query)
+		if err != nil {
+			return
+		}
+		question, err := p.Question()
+		if err != nil {
+			return
+		}
+		queries.Add(1)
+
+		header.Response, header.Authoritative = true, true
+		b := dnsmessage.NewBuilder(nil, header)
+		b.EnableCompression()
+		b.StartQuestions()
+		b.Question(question)
+		b.StartAnswers()
+		if question.Type == dnsmessage.TypeA {
+			b.AResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60},
+				dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}})
+		}
+		answer, err := b.Finish()
+		if err != nil {
+			return
+		}
+		binary.BigEndian.PutUint16(size[:], uint16(len(answer)))
+		if _, err := conn.Write(append(size[:], answer...)); err != nil {
+			return
+		}
+	}
+}
+
+func TestWithResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	tests := []struct {
+		name string
+		host string
+	}{
+		{"made up name", "scraper-test.example"},
+		{"subdomain", "a.b.scraper-test.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries atomic.Int32
+			s := NewSimpleScraper(5*time.Second, WithResolver(fakeResolver(&queries)))
+			defer s.Close()
+
+			addr := net.JoinHostPort(tt.host, port)
+			data, err := s.Scrape(context.Background(), "http://"+addr+"/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the request still names the host, only the lookup was redirected
+			if string(data) != addr {
+				t.Errorf("the server saw host %q, want %q", data, addr)
+			}
+			if queries.Load() == 0 {
+				t.Error("the custom resolver wasn't asked")
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package learning
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change
+const diffContext = 3
+
+// maxDiffCells caps the size of the table the line diff works on, texts that
+// differ in more lines are diffed as one replaced block
+const maxDiffCells = 4_000_000
+
+// diffLine is a line of a diff, op is ' ', '-' or '+'
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff returns the changes from old to new in unified diff format, empty
+// when the texts are equal
+func UnifiedDiff(oldName, newName, old, new string) string {
+	lines := diffLines(splitLines(old), splitLines(new))
+
+	var sb strings.Builder
+	for _, h := range diffHunks(lines) {
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		sb.WriteString(h)
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit from a to b by the longest common subsequence
+func diffLines(a, b []string) []diffLine {
+	// the common start and end cost nothing, usually most of a page
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, s := range a[:prefix] {
+		lines = append(lines, diffLine{' ', s})
+	}
+	lines = append(lines, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, s := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', s})
+	}
+	return lines
+}
+
+func diffMiddle(a, b []string) []diffLine {
+	var lines []diffLine
+	if len(a)*len(b) > maxDiffCells {
+		for _, s := range a {
+			lines = append(lines, diffLine{'-', s})
+		}
+		for _, s := range b {
+			lines = append(lines, diffLine{'+', s})
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// diffHunks formats the changed parts of lines with diffContext lines around them
+func diffHunks(lines []diffLine) []string {
+	var hunks []string
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(lines); {
+		// find the next change
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		// the unchanged lines skipped since the last hunk
+		from := max(start, first-diffContext)
+		oldLine += from - start
+		newLine += from - start
+
+		// extend the hunk while the changes are close enough to share context
+		end, unchanged := first, 0
+		for end < len(lines) && unchanged <= 2*diffContext {
+			if lines[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		end -= max(unchanged-diffContext, 0)
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for _, l := range lines[from:end] {
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		hunks = append(hunks, fmt.Sprintf("@@ -%s +%s @@\n%s",
+			hunkRange(oldLine, oldCount), hunkRange(newLine, newCount), body.String()))
+		oldLine += oldCount
+		newLine += newCount
+		start = end
+	}
+	return hunks
+}
+
+// hunkRange formats the start and length of a hunk side, an empty side starts
+// at the line before it
+func hunkRange(line, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line-1)
+	case 1:
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
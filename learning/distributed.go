@@ -0,0 +1,408 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// RemoteError is the error of a result scraped by a RemoteWorker, only its
+// text, ErrorClass and status code survive the trip to the coordinator. It
+// matches the error of its class, so errors.Is works as it would locally.
+type RemoteError struct {
+	Class   string
+	Message string
+	// Status is the code of an ErrBadStatus, zero for other errors
+	Status int
+}
+
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+func (e *RemoteError) Unwrap() error {
+	switch {
+	case e.Status != 0:
+		return ErrBadStatus{Code: e.Status}
+	case e.Class == "timeout":
+		return ErrTimeout
+	case e.Class == "canceled":
+		return context.Canceled
+	}
+	return classErrors[e.Class]
+}
+
+// Coordinator hands urls to RemoteWorkers over TCP and collects their results,
+// spreading a crawl over several processes or machines. A url whose worker
+// doesn't report back within Lease is handed out again.
+//
+// The rpc protocol has no authentication, anyone who can reach the listener
+// can take urls and report made up results, so only listen on trusted networks.
+type Coordinator struct {
+	// Discover returns the urls found in a result, they are added to the crawl
+	Discover func(Result) []string
+	// MaxPages caps the number of urls a crawl grows to, zero means no limit
+	MaxPages int
+	// Lease is how long a worker may take for a url, a minute when zero
+	Lease time.Duration
+
+	mu      sync.Mutex
+	queue   urlQueue
+	leased  map[string]time.Time
+	seen    *Deduper
+	pages   int
+	results []Result
+	// changed is closed and replaced whenever urls are queued or finished
+	changed chan struct{}
+}
+
+// CoordinatorOption configures a Coordinator
+type CoordinatorOption func(*Coordinator)
+
+// WithCoordinatorDiscover feeds the urls found by discover back into the crawl,
+// every url is scraped once and the crawl stops growing after maxPages urls
+func WithCoordinatorDiscover(discover func(Result) []string, maxPages int) CoordinatorOption {
+	return func(c *Coordinator) {
+		c.Discover = discover
+		c.MaxPages = maxPages
+	}
+}
+
+// WithLease sets how long a worker may hold a url before it is handed out again
+func WithLease(d time.Duration) CoordinatorOption {
+	return func(c *Coordinator) {
+		c.Lease = d
+	}
+}
+
+// NewCoordinator creates a new Coordinator
+func NewCoordinator(opts ...CoordinatorOption) *Coordinator {
+	c := &Coordinator{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run serves workers on ln until every url, including the discovered ones, has
+// a result or ctx is done, then closes ln. Workers that ask for more work are
+// told the crawl is over and exit. Urls are fetched and reported as given,
+// they are only normalized to tell duplicates apart. ln must only be
+// reachable from trusted networks.
+func (c *Coordinator) Run(ctx context.Context, ln net.Listener, urls []string) ([]Result, error) {
+	c.mu.Lock()
+	c.queue = urlQueue{}
+	c.leased = make(map[string]time.Time)
+	c.seen = NewDeduper()
+	c.pages = 0
+	c.results = nil
+	c.changed = make(chan struct{})
+	for _, url := range urls {
+		if c.seen.Add(url) {
+			c.pages++
+			c.queue.push(url, 0)
+		}
+	}
+	c.mu.Unlock()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Coordinator", &coordinatorService{c: c}); err != nil {
+		return nil, fmt.Errorf("failed to register coordinator: %w", err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		connMu sync.Mutex
+		conns  = make(map[net.Conn]struct{})
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			connMu.Lock()
+			conns[conn] = struct{}{}
+			connMu.Unlock()
+			go func() {
+				server.ServeConn(conn)
+				connMu.Lock()
+				delete(conns, conn)
+				connMu.Unlock()
+			}()
+		}
+	}()
+
+	err := c.wait(ctx)
+	ln.Close()
+	wg.Wait()
+	if err != nil {
+		// finished workers hang up by themselves, the others are cut off
+		connMu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		connMu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.results, err
+}
+
+// wait blocks until the crawl is done or ctx is
+func (c *Coordinator) wait(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		finished := c.queue.Len() == 0 && len(c.leased) == 0
+		changed := c.changed
+		c.mu.Unlock()
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("crawl stopped early: %w", context.Cause(ctx))
+		}
+	}
+}
+
+func (c *Coordinator) lease() time.Duration {
+	if c.Lease <= 0 {
+		return time.Minute
+	}
+	return c.Lease
+}
+
+// notify wakes everyone waiting for a change, c.mu must be held
+func (c *Coordinator) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// next leases the next url. It waits up to wait for one, done is set once the
+// crawl is over.
+func (c *Coordinator) next(wait time.Duration) (url string, done bool) {
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		expiry := time.Duration(0)
+		for leased, deadline := range c.leased {
+			if left := deadline.Sub(now); left <= 0 {
+				// the worker is gone or stuck, let another one try
+				delete(c.leased, leased)
+				c.queue.push(leased, 0)
+			} else if expiry == 0 || left < expiry {
+				expiry = left
+			}
+		}
+		if url, ok := c.queue.pop(); ok {
+			c.leased[url] = now.Add(c.lease())
+			c.mu.Unlock()
+			return url, false
+		}
+		if len(c.leased) == 0 {
+			c.mu.Unlock()
+			return "", true
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		if expiry <= 0 || expiry > wait {
+			expiry = wait
+		}
+		expired := time.NewTimer(expiry)
+		select {
+		case <-changed:
+		case <-expired.C:
+		case <-timeout.C:
+			expired.Stop()
+			return "", false
+		}
+		expired.Stop()
+	}
+}
+
+// report records the result of a leased url and queues the urls discovered on it
+func (c *Coordinator) report(result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.leased[result.URL]; !ok {
+		// a late report for a url that was handed out again, or never at all
+		return
+	}
+	delete(c.leased, result.URL)
+	c.results = append(c.results, result)
+	if c.Discover != nil && result.Err == nil {
+		for _, next := range c.Discover(result) {
+			if c.MaxPages > 0 && c.pages >= c.MaxPages {
+				break
+			}
+			if c.seen.Add(next) {
+				c.pages++
+				c.queue.push(next, 0)
+			}
+		}
+	}
+	c.notify()
+}
+
+// coordinatorService exposes a Coordinator over net/rpc
+type coordinatorService struct {
+	c *Coordinator
+}
+
+// pollWait is how long a Next call waits for work before the worker asks again
+const pollWait = 10 * time.Second
+
+// RemoteJob is a url handed to a RemoteWorker, net/rpc needs it exported
+type RemoteJob struct {
+	URL string
+	// Done tells the worker the crawl is over
+	Done bool
+}
+
+// RemoteResult is a Result as a RemoteWorker sends it to the Coordinator
+type RemoteResult struct {
+	URL        string
+	Data       []byte
+	Err        *RemoteError
+	StatusCode int
+	Attempts   int
+	Started    time.Time
+	Duration   time.Duration
+	Header     http.Header
+	Redirects  []string
+}
+
+func (s *coordinatorService) Next(_ struct{}, job *RemoteJob) error {
+	job.URL, job.Done = s.c.next(pollWait)
+	return nil
+}
+
+func (s *coordinatorService) Report(r RemoteResult, _ *struct{}) error {
+	result := Result{
+		URL:        r.URL,
+		Data:       r.Data,
+		StatusCode: r.StatusCode,
+		Attempts:   r.Attempts,
+		Started:    r.Started,
+		Duration:   r.Duration,
+		Header:     r.Header,
+		Redirects:  r.Redirects,
+	}
+	if r.Err != nil {
+		result.Err = r.Err
+	}
+	s.c.report(result)
+	return nil
+}
+
+// RemoteWorker scrapes the urls a Coordinator hands out and reports the results back
+type RemoteWorker struct {
+	Scraper Scraper
+	// Addr is the address of the coordinator
+	Addr string
+	// Concurrency is the number of urls scraped at once, at least one
+	Concurrency int
+}
+
+// NewRemoteWorker creates a RemoteWorker
+func NewRemoteWorker(scraper Scraper, addr string, concurrency int) *RemoteWorker {
+	return &RemoteWorker{Scraper: scraper, Addr: addr, Concurrency: concurrency}
+}
+
+// Run works for the coordinator until the crawl is over or ctx is done
+func (w *RemoteWorker) Run(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", w.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to coordinator: %w", err)
+	}
+	client := rpc.NewClient(conn)
+	defer client.Close()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for range max(w.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.work(ctx, client); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (w *RemoteWorker) work(ctx context.Context, client *rpc.Client) error {
+	for {
+		var job RemoteJob
+		if err := call(ctx, client, "Coordinator.Next", struct{}{}, &job); err != nil {
+			return fmt.Errorf("failed to get work: %w", err)
+		}
+		if job.Done {
+			return nil
+		}
+		if job.URL == "" {
+			continue
+		}
+
+		result := scrapeOne(ctx, w.Scraper, job.URL)
+		if ctx.Err() != nil {
+			// the lease runs out and another worker picks the url up
+			return ctx.Err()
+		}
+		r := RemoteResult{
+			URL:        job.URL,
+			Data:       result.Data,
+			StatusCode: result.StatusCode,
+			Attempts:   result.Attempts,
+			Started:    result.Started,
+			Duration:   result.Duration,
+			Header:     result.Header,
+			Redirects:  result.Redirects,
+		}
+		if result.Err != nil {
+			r.Err = &RemoteError{Class: ErrorClass(result.Err), Message: result.Err.Error()}
+			var bad ErrBadStatus
+			if errors.As(result.Err, &bad) {
+				r.Err.Status = bad.Code
+			}
+		}
+		if err := call(ctx, client, "Coordinator.Report", r, &struct{}{}); err != nil {
+			return fmt.Errorf("failed to report %s: %w", job.URL, err)
+		}
+	}
+}
+
+// call makes an rpc call that gives up when ctx is done
+func call(ctx context.Context, client *rpc.Client, method string, args, reply any) error {
+	c := client.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-c.Done:
+		return c.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,79 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCoordinatorRawURLs(t *testing.T) {
+	raw := "https://Example.com/page?b=1&a=2"
+	stub := NewStubScraper(map[string][]byte{raw: []byte("page")})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	worker := NewRemoteWorker(stub, ln.Addr().String(), 2)
+	workerErr := make(chan error, 1)
+	go func() { workerErr <- worker.Run(ctx) }()
+
+	// the second url is the first one normalized, it is only scraped once
+	urls := []string{raw, "https://example.com/page?a=2&b=1", "https://example.com/missing"}
+	results, err := NewCoordinator().Run(ctx, ln, urls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-workerErr; err != nil {
+		t.Fatal(err)
+	}
+
+	byURL := map[string]Result{}
+	for _, r := range results {
+		byURL[r.URL] = r
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if r := byURL[raw]; r.Err != nil || string(r.Data) != "page" {
+		t.Errorf("%s = %q, %v, want the page fetched by its raw url", raw, r.Data, r.Err)
+	}
+	if r := byURL["https://example.com/missing"]; !errors.Is(r.Err, ErrNotFound) {
+		t.Errorf("missing url failed with %v, want ErrNotFound", r.Err)
+	}
+}
+
+func TestRemoteErrorIs(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"not found", ErrNotFound, ErrNotFound},
+		{"bad status", ErrBadStatus{Code: 503}, ErrBadStatus{Code: 503}},
+		{"robots", ErrRobotsDisallowed, ErrRobotsDisallowed},
+		{"too large", ErrTooLarge, ErrTooLarge},
+		{"timeout", ErrTimeout, ErrTimeout},
+		{"canceled", context.Canceled, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &RemoteError{Class: ErrorClass(tt.err), Message: tt.err.Error()}
+			var bad ErrBadStatus
+			if errors.As(tt.err, &bad) {
+				remote.Status = bad.Code
+			}
+			if !errors.Is(remote, tt.want) {
+				t.Errorf("%v (class %q) doesn't match %v", remote, remote.Class, tt.want)
+			}
+		})
+	}
+	if errors.Is(&RemoteError{Class: "status", Status: 503}, ErrBadStatus{Code: 404}) {
+		t.Error("a remote 503 matches a 404")
+	}
+}
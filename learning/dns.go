@@ -0,0 +1,139 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DNSCache remembers host lookups for TTL and failed lookups for NegativeTTL,
+// so repeated requests to the same hosts skip resolution. One cache can be
+// shared by several scrapers.
+type DNSCache struct {
+	// Resolver does the lookups, net.DefaultResolver when nil
+	Resolver    *net.Resolver
+	TTL         time.Duration
+	NegativeTTL time.Duration
+	// Clock expires the entries, RealClock when nil
+	Clock Clock
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+	group   singleflight.Group
+}
+
+// dnsEntry is a cached lookup, err is set for a negative entry
+type dnsEntry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// NewDNSCache creates a DNSCache, a zero negativeTTL doesn't cache failures
+func NewDNSCache(ttl, negativeTTL time.Duration) *DNSCache {
+	return &DNSCache{TTL: ttl, NegativeTTL: negativeTTL}
+}
+
+// WithDNSCache makes the scraper resolve host names through cache. It has no
+// effect on connections made through a SOCKS5 proxy, which resolves names itself.
+func WithDNSCache(cache *DNSCache) Option {
+	return func(s *SimpleScraper) {
+		s.dns = cache
+	}
+}
+
+// LookupHost returns the addresses of host, from the cache while they are fresh.
+// Concurrent lookups of the same host share one query.
+func (d *DNSCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	now := d.now()
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, entry.err
+	}
+
+	// the query outlives a caller that gives up, the others may still want it
+	ch := d.group.DoChan(host, func() (any, error) {
+		return d.lookup(context.WithoutCancel(ctx), host)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]string), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (d *DNSCache) lookup(ctx context.Context, host string) ([]string, error) {
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupHost(ctx, host)
+
+	ttl := d.TTL
+	if err != nil {
+		ttl = d.NegativeTTL
+	}
+	if ttl > 0 {
+		d.mu.Lock()
+		if d.entries == nil {
+			d.entries = make(map[string]dnsEntry)
+		}
+		d.entries[host] = dnsEntry{addrs: addrs, err: err, expires: d.now().Add(ttl)}
+		d.mu.Unlock()
+	}
+	return addrs, err
+}
+
+// Flush forgets every cached lookup
+func (d *DNSCache) Flush() {
+	d.mu.Lock()
+	d.entries = nil
+	d.mu.Unlock()
+}
+
+func (d *DNSCache) now() time.Time {
+	if d.Clock == nil {
+		return RealClock.Now()
+	}
+	return d.Clock.Now()
+}
+
+// dialCached resolves the host of addr through the DNS cache and dials its
+// addresses one after the other until a connection succeeds
+func (s *SimpleScraper) dialCached(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return s.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := s.dns.LookupHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	var errs []error
+	for _, ip := range addrs {
+		conn, err := s.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", host)
+	}
+	return nil, errors.Join(errs...)
+}
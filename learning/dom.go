@@ -0,0 +1,44 @@
+package learning
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ScrapeDOM fetches url and parses the body into an html node tree, a 304
+// answer to a conditional request gives ErrNotModified
+func (s *SimpleScraper) ScrapeDOM(ctx context.Context, url string) (*html.Node, error) {
+	result := s.ScrapeResult(ctx, url)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	if result.NotModified {
+		return nil, fmt.Errorf("%w: %s", ErrNotModified, result.URL)
+	}
+
+	r, err := utf8Reader(result.Data, result.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+	return doc, nil
+}
+
+// utf8Reader converts a body to utf-8, using the charset from the content type
+// or from the page itself
+func utf8Reader(data []byte, contentType string) (io.Reader, error) {
+	r, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect charset: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,62 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapValidators is a ValidatorStore in memory
+type mapValidators struct {
+	mu sync.Mutex
+	m  map[string]Validators
+}
+
+func (s *mapValidators) Get(url string) (Validators, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.m[url]
+	return v, ok
+}
+
+func (s *mapValidators) Set(url string, v Validators) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = map[string]Validators{}
+	}
+	s.m[url] = v
+	return nil
+}
+
+// etagServer serves body with an ETag and answers 304 when it is sent back
+func etagServer(t *testing.T, contentType, body string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestScrapeDOMNotModified(t *testing.T) {
+	srv := etagServer(t, "text/html", "<p>hello</p>")
+	s := NewSimpleScraper(5*time.Second, WithConditionalRequests(&mapValidators{}))
+
+	if doc, err := s.ScrapeDOM(context.Background(), srv.URL); err != nil || doc == nil {
+		t.Fatalf("first ScrapeDOM() = %v, %v", doc, err)
+	}
+	doc, err := s.ScrapeDOM(context.Background(), srv.URL)
+	if !errors.Is(err, ErrNotModified) || doc != nil {
+		t.Errorf("second ScrapeDOM() = %v, %v, want ErrNotModified", doc, err)
+	}
+}
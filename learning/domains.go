@@ -0,0 +1,136 @@
+package learning
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ErrFiltered is matched by the error of a url the DomainFilter rejected
+var ErrFiltered = errors.New("filtered")
+
+// FilteredError is the error of a url whose host the DomainFilter rejected, it
+// matches ErrFiltered and ErrSkipped so the url counts as skipped rather than failed
+type FilteredError struct {
+	URL string
+	// Rule is the exclude rule that matched, empty when no include rule did
+	Rule string
+}
+
+func (e *FilteredError) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("%s: %s matches no include rule", ErrFiltered, e.URL)
+	}
+	return fmt.Sprintf("%s: %s matches exclude rule %q", ErrFiltered, e.URL, e.Rule)
+}
+
+// Is makes errors.Is(err, ErrFiltered) and errors.Is(err, ErrSkipped) hold
+func (e *FilteredError) Is(target error) bool {
+	return target == ErrFiltered || target == ErrSkipped
+}
+
+// HostRule matches the host of a url. Rules are written as an exact host such
+// as "example.com", a wildcard such as "*.example.com" where * also spans
+// dots, or a regular expression between slashes such as "/^cdn[0-9]+\./".
+type HostRule struct {
+	pattern string
+	match   func(host string) bool
+}
+
+// ParseHostRule parses a rule, hosts are compared case-insensitively
+func ParseHostRule(pattern string) (HostRule, error) {
+	rule := HostRule{pattern: pattern}
+	switch {
+	case len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/"):
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return HostRule{}, fmt.Errorf("invalid host rule %q: %w", pattern, err)
+		}
+		rule.match = re.MatchString
+	case strings.ContainsAny(pattern, "*?["):
+		wildcard := strings.ToLower(pattern)
+		// hosts contain no slashes, so * matches across the dots as well
+		if _, err := path.Match(wildcard, ""); err != nil {
+			return HostRule{}, fmt.Errorf("invalid host rule %q: %w", pattern, err)
+		}
+		rule.match = func(host string) bool {
+			ok, _ := path.Match(wildcard, host)
+			return ok
+		}
+	case pattern == "" || strings.ContainsAny(pattern, "/: "):
+		return HostRule{}, fmt.Errorf("invalid host rule %q", pattern)
+	default:
+		host := strings.ToLower(pattern)
+		rule.match = func(h string) bool { return h == host }
+	}
+	return rule, nil
+}
+
+// Match reports whether host matches the rule
+func (r HostRule) Match(host string) bool {
+	return r.match(strings.ToLower(host))
+}
+
+func (r HostRule) String() string {
+	return r.pattern
+}
+
+// DomainFilter decides which urls are fetched by their host. A url is fetched
+// when it matches an include rule, or there are none, and no exclude rule.
+type DomainFilter struct {
+	Include []HostRule
+	Exclude []HostRule
+}
+
+// NewDomainFilter parses the include and exclude rules, see HostRule for the syntax
+func NewDomainFilter(include, exclude []string) (*DomainFilter, error) {
+	var f DomainFilter
+	var errs []error
+	for _, pattern := range include {
+		rule, err := ParseHostRule(pattern)
+		errs = append(errs, err)
+		f.Include = append(f.Include, rule)
+	}
+	for _, pattern := range exclude {
+		rule, err := ParseHostRule(pattern)
+		errs = append(errs, err)
+		f.Exclude = append(f.Exclude, rule)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Check returns a *FilteredError when rawURL must not be fetched, a nil
+// DomainFilter allows every url
+func (f *DomainFilter) Check(rawURL string) error {
+	if f == nil {
+		return nil
+	}
+	host := hostOf(rawURL)
+	for _, rule := range f.Exclude {
+		if rule.Match(host) {
+			return &FilteredError{URL: rawURL, Rule: rule.pattern}
+		}
+	}
+	if len(f.Include) == 0 {
+		return nil
+	}
+	for _, rule := range f.Include {
+		if rule.Match(host) {
+			return nil
+		}
+	}
+	return &FilteredError{URL: rawURL}
+}
+
+// WithDomainFilter only fetches the urls f allows, the seeds and discovered
+// urls it rejects are reported once with a *FilteredError
+func WithDomainFilter(f *DomainFilter) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Domains = f
+	}
+}
@@ -0,0 +1,55 @@
+package learning
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDomainFilter(t *testing.T) {
+	f, err := NewDomainFilter(
+		[]string{"example.com", "*.example.com", "/^cdn[0-9]+\\.net$/"},
+		[]string{"ads.example.com", "*.internal.example.com"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/", true},
+		{"https://EXAMPLE.com/", true},
+		{"https://www.example.com/", true},
+		{"https://a.b.example.com/", true},
+		{"https://cdn12.net/lib.js", true},
+		{"https://cdn.net/", false},
+		{"https://notexample.com/", false},
+		{"https://ads.example.com/", false},
+		{"https://db.internal.example.com/", false},
+		{"https://example.org/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := f.Check(tt.url)
+			if got := err == nil; got != tt.want {
+				t.Fatalf("Check(%q) = %v, want allowed %v", tt.url, err, tt.want)
+			}
+			if err != nil && (!errors.Is(err, ErrFiltered) || !errors.Is(err, ErrSkipped)) {
+				t.Errorf("Check(%q) = %v, want it to match ErrFiltered and ErrSkipped", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestParseHostRuleErrors(t *testing.T) {
+	for _, pattern := range []string{"", "/(/", "a[b", "example.com:80", "https://example.com"} {
+		if _, err := ParseHostRule(pattern); err == nil {
+			t.Errorf("ParseHostRule(%q) succeeded", pattern)
+		}
+	}
+	var nilFilter *DomainFilter
+	if err := nilFilter.Check("https://example.com"); err != nil {
+		t.Errorf("nil DomainFilter rejected a url: %v", err)
+	}
+}
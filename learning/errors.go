@@ -0,0 +1,73 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrTimeout is in the chain of every error caused by one of the scraper's
+// timeouts, whether the client, the dialer or a WithRequestTimeout ran out. When
+// the caller's ctx runs out the error only matches context.DeadlineExceeded.
+var ErrTimeout = errors.New("timed out")
+
+// ErrInvalidRequest is returned when a request can't even be built, for example
+// for a malformed url
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrTooLarge is returned for responses bigger than the scraper's MaxBodyBytes
+var ErrTooLarge = errors.New("response body too large")
+
+// ErrBadStatus is the error of a response with an unexpected status code. With a
+// zero Code it matches every status in errors.Is:
+//
+//	var bad ErrBadStatus
+//	if errors.As(result.Err, &bad) && bad.Code == http.StatusNotFound { ... }
+//	if errors.Is(result.Err, ErrBadStatus{}) { ... }
+type ErrBadStatus struct {
+	Code int
+}
+
+func (e ErrBadStatus) Error() string {
+	return fmt.Sprintf("bad status code: %d", e.Code)
+}
+
+// Is matches an ErrBadStatus with the same Code, or any code when target's is zero
+func (e ErrBadStatus) Is(target error) bool {
+	t, ok := target.(ErrBadStatus)
+	return ok && (t.Code == 0 || t.Code == e.Code)
+}
+
+// timeoutError puts ErrTimeout in the chain of err without changing its text
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string   { return e.err.Error() }
+func (e *timeoutError) Unwrap() []error { return []error{ErrTimeout, e.err} }
+
+// markTimeout adds ErrTimeout to err when one of the scraper's own timeouts
+// caused it. Once the caller's ctx is done the failure is the caller's doing,
+// so err only carries ctx's error and not ErrTimeout.
+func markTimeout(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if errors.Is(err, ErrTimeout) {
+			// a dial or client timeout hit by the caller's deadline
+			return fmt.Errorf("%w: %s", ctxErr, err)
+		}
+		return err
+	}
+	if errors.Is(err, ErrTimeout) || !isTimeout(err) {
+		return err
+	}
+	return &timeoutError{err: err}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,71 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		clientTimeout time.Duration
+		ctxTimeout    time.Duration
+		wantTimeout   bool
+	}{
+		{"client timeout", 20 * time.Millisecond, time.Second, true},
+		{"caller deadline", time.Second, 20 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.ctxTimeout)
+			defer cancel()
+
+			result := NewSimpleScraper(tt.clientTimeout).ScrapeResult(ctx, srv.URL)
+			if result.Err == nil {
+				t.Fatal("want an error")
+			}
+			if got := errors.Is(result.Err, ErrTimeout); got != tt.wantTimeout {
+				t.Errorf("errors.Is(%v, ErrTimeout) = %v, want %v", result.Err, got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestMarkTimeout(t *testing.T) {
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	connect := errors.Join(ErrConnectTimeout, errors.New("dial tcp: i/o timeout"))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"nil", context.Background(), nil, false},
+		{"other error", context.Background(), errors.New("refused"), false},
+		{"deadline", context.Background(), context.DeadlineExceeded, true},
+		{"connect", context.Background(), connect, true},
+		{"connect after cancel", done, connect, false},
+		{"deadline after cancel", done, context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(markTimeout(tt.ctx, tt.err), ErrTimeout); got != tt.want {
+				t.Errorf("errors.Is(markTimeout(%v), ErrTimeout) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
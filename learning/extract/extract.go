@@ -0,0 +1,95 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Fields holds the values found on a page by field name
+type Fields map[string][]string
+
+// Extractor turns a scraped page into structured fields
+type Extractor interface {
+	Extract(data []byte) (Fields, error)
+}
+
+// Rule tells how a field is selected
+type Rule struct {
+	Selector string
+	// Attr reads an attribute, such as href, instead of the text of the element
+	Attr string
+}
+
+// CSSExtractor implements the Extractor interface with CSS selectors
+type CSSExtractor struct {
+	rules map[string]compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	sel cascadia.Sel
+}
+
+// NewCSSExtractor creates a CSSExtractor, it fails on invalid selectors
+func NewCSSExtractor(rules map[string]Rule) (*CSSExtractor, error) {
+	e := &CSSExtractor{rules: make(map[string]compiledRule, len(rules))}
+	for field, rule := range rules {
+		sel, err := cascadia.Parse(rule.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector for %s: %w", field, err)
+		}
+		e.rules[field] = compiledRule{Rule: rule, sel: sel}
+	}
+	return e, nil
+}
+
+// Extract parses the page and collects the values of every field
+func (e *CSSExtractor) Extract(data []byte) (Fields, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	fields := Fields{}
+	for field, rule := range e.rules {
+		for _, n := range cascadia.QueryAll(doc, rule.sel) {
+			if value := rule.value(n); value != "" {
+				fields[field] = append(fields[field], value)
+			}
+		}
+	}
+	return fields, nil
+}
+
+func (r compiledRule) value(n *html.Node) string {
+	if r.Attr == "" {
+		return text(n)
+	}
+	for _, attr := range n.Attr {
+		if attr.Key == r.Attr {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+// text returns the text inside n with whitespace collapsed
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,45 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FailoverScraper tries its backends in order and returns the first success
+type FailoverScraper struct {
+	Backends []Scraper
+}
+
+// NewFailoverScraper creates a FailoverScraper, the first backend is the primary
+func NewFailoverScraper(backends ...Scraper) *FailoverScraper {
+	return &FailoverScraper{Backends: backends}
+}
+
+// Name identifies the FailoverScraper
+func (f *FailoverScraper) Name() string {
+	return "failover"
+}
+
+// Scrape fetches url from the first backend that succeeds, if all of them fail
+// the errors of every attempt are joined
+func (f *FailoverScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	if len(f.Backends) == 0 {
+		return nil, ErrNoBackends
+	}
+
+	var errs []error
+	for _, backend := range f.Backends {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Join(append(errs, err)...)
+		}
+
+		data, err := backend.Scrape(ctx, url)
+		if err == nil {
+			return data, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", ScraperName(backend), err))
+	}
+
+	return nil, errors.Join(errs...)
+}
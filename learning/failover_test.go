@@ -0,0 +1,48 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFailoverScraper(t *testing.T) {
+	down := errors.New("down")
+	page := NewStubScraper(map[string][]byte{"https://example.com/": []byte("page")})
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		backends  []Scraper
+		want      string
+		wantErrs  []error
+		wantCalls []int
+	}{
+		{"primary", context.Background(), []Scraper{page, &failingScraper{err: down}}, "page", nil, []int{0, 0}},
+		{"second", context.Background(), []Scraper{&failingScraper{err: down}, page}, "page", nil, []int{1, 0}},
+		{"all fail", context.Background(), []Scraper{&failingScraper{err: down}, &failingScraper{err: ErrBadStatus{Code: 503}}},
+			"", []error{down, ErrBadStatus{Code: 503}}, []int{1, 1}},
+		{"cancelled", cancelled, []Scraper{&failingScraper{err: down}}, "", []error{context.Canceled}, []int{0}},
+		{"none", context.Background(), nil, "", []error{ErrNoBackends}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := NewFailoverScraper(tt.backends...).Scrape(tt.ctx, "https://example.com/")
+			if string(data) != tt.want || (err == nil) != (tt.wantErrs == nil) {
+				t.Fatalf("Scrape() = %q, %v, want %q", data, err, tt.want)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("error %v doesn't match %v", err, want)
+				}
+			}
+			for i, backend := range tt.backends {
+				if f, ok := backend.(*failingScraper); ok && f.calls != tt.wantCalls[i] {
+					t.Errorf("backend %d was called %d times, want %d", i, f.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
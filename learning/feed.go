@@ -0,0 +1,165 @@
+package learning
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnknownFeed is returned for documents that are neither RSS nor Atom
+var ErrUnknownFeed = errors.New("not an rss or atom feed")
+
+// Feed is a parsed RSS or Atom feed
+type Feed struct {
+	Title string
+	Items []FeedItem
+}
+
+// FeedItem is an entry of a feed, Published is zero when the feed has no usable date
+type FeedItem struct {
+	ID        string
+	Title     string
+	Link      string
+	Published time.Time
+}
+
+// FeedScraper fetches feeds with Scraper and parses them. Wrap Scraper with
+// Chain to get retries, rate limiting or caching for feed polling.
+type FeedScraper struct {
+	Scraper Scraper
+}
+
+// NewFeedScraper creates a FeedScraper
+func NewFeedScraper(scraper Scraper) *FeedScraper {
+	return &FeedScraper{Scraper: scraper}
+}
+
+// ScrapeFeed fetches and parses the feed at url
+func (f *FeedScraper) ScrapeFeed(ctx context.Context, url string) (*Feed, error) {
+	data, err := f.Scraper.Scrape(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := ParseFeed(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed %s: %w", url, err)
+	}
+	return feed, nil
+}
+
+// rssDoc matches RSS 2.0 and RSS 1.0 (RDF), which keeps its items next to the channel
+type rssDoc struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	GUID    string `xml:"guid"`
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	PubDate string `xml:"pubDate"`
+	// Date is the Dublin Core date RSS 1.0 feeds use
+	Date string `xml:"date"`
+}
+
+type atomDoc struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// ParseFeed parses an RSS 2.0, RSS 1.0 or Atom document
+func ParseFeed(data []byte) (*Feed, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(root.XMLName.Local) {
+	case "rss", "rdf":
+		var doc rssDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		feed := &Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+		for _, item := range append(doc.Channel.Items, doc.Items...) {
+			feed.Items = append(feed.Items, FeedItem{
+				ID:        strings.TrimSpace(item.GUID),
+				Title:     strings.TrimSpace(item.Title),
+				Link:      strings.TrimSpace(item.Link),
+				Published: parseFeedTime(item.PubDate, item.Date),
+			})
+		}
+		return feed, nil
+
+	case "feed":
+		var doc atomDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		feed := &Feed{Title: strings.TrimSpace(doc.Title)}
+		for _, entry := range doc.Entries {
+			item := FeedItem{
+				ID:        strings.TrimSpace(entry.ID),
+				Title:     strings.TrimSpace(entry.Title),
+				Published: parseFeedTime(entry.Published, entry.Updated),
+			}
+			// the alternate link points at the page, a link without rel is alternate too
+			for _, link := range entry.Links {
+				if link.Rel == "" || link.Rel == "alternate" {
+					item.Link = link.Href
+					break
+				}
+			}
+			if item.Link == "" && len(entry.Links) > 0 {
+				item.Link = entry.Links[0].Href
+			}
+			feed.Items = append(feed.Items, item)
+		}
+		return feed, nil
+	}
+
+	return nil, fmt.Errorf("%w: root element %q", ErrUnknownFeed, root.XMLName.Local)
+}
+
+// feedTimeLayouts covers the RFC 822 dates of RSS, including the common
+// variations, and the RFC 3339 dates of Atom and Dublin Core
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// parseFeedTime returns the first of values that parses
+func parseFeedTime(values ...string) time.Time {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		for _, layout := range feedTimeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
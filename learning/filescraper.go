@@ -0,0 +1,87 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a scraper has nothing stored for a url
+var ErrNotFound = errors.New("not found")
+
+// FileScraper implements the Scraper interface by reading pages from disk,
+// which makes it possible to work against a downloaded snapshot offline
+type FileScraper struct {
+	BaseDir string
+	// PathFor maps a url to a file path, when nil the url is mapped below BaseDir
+	PathFor func(rawURL string) (string, error)
+}
+
+// NewFileScraper creates a FileScraper that reads from baseDir/<host>/<path>
+func NewFileScraper(baseDir string) *FileScraper {
+	return &FileScraper{BaseDir: baseDir}
+}
+
+// Name identifies the FileScraper
+func (f *FileScraper) Name() string {
+	return "file"
+}
+
+// Scrape reads the file the url maps to, a missing file fails like a 404
+// response: the error matches both ErrNotFound and ErrBadStatus{Code: 404}
+func (f *FileScraper) Scrape(ctx context.Context, rawURL string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name, err := f.path(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s: %w", ErrNotFound, rawURL, ErrBadStatus{Code: http.StatusNotFound})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+
+	return data, nil
+}
+
+func (f *FileScraper) path(rawURL string) (string, error) {
+	if f.PathFor != nil {
+		return f.PathFor(rawURL)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	host := u.Hostname()
+	if host == "." || host == ".." || strings.ContainsAny(host, `/\`) {
+		return "", fmt.Errorf("%w: host %q can't be mapped to a file", ErrInvalidRequest, host)
+	}
+
+	// cleaning a rooted path drops any ".." in the path
+	p := path.Clean("/" + u.Path)
+	if p == "/" || strings.HasSuffix(u.Path, "/") {
+		p = path.Join(p, "index.html")
+	}
+
+	base := filepath.Clean(f.BaseDir)
+	name := filepath.Join(base, host, filepath.FromSlash(p))
+	if rel, err := filepath.Rel(base, name); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s maps outside of %s", ErrInvalidRequest, rawURL, f.BaseDir)
+	}
+	return name, nil
+}
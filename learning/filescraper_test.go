@@ -0,0 +1,61 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileScraper(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "base")
+	files := map[string]string{
+		"base/example.com/index.html":      "home",
+		"base/example.com/docs/index.html": "docs",
+		"base/example.com/docs/page.html":  "page",
+		"secret":                           "outside",
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		url     string
+		want    string
+		wantErr error
+	}{
+		{"https://example.com", "home", nil},
+		{"https://example.com/", "home", nil},
+		{"https://example.com/docs/", "docs", nil},
+		{"https://example.com/docs/page.html", "page", nil},
+		{"https://example.com/../../secret", "", ErrNotFound},
+		{"https://example.com/missing.html", "", ErrBadStatus{Code: 404}},
+		{"https://other.com/", "", ErrNotFound},
+		{"http://../secret", "", ErrInvalidRequest},
+		{"http://./base/example.com/", "", ErrInvalidRequest},
+		{`http://a\..\..\secret/`, "", ErrInvalidRequest},
+	}
+	s := NewFileScraper(base)
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			data, err := s.Scrape(context.Background(), tt.url)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %q, %v, want error %v", data, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(data) != tt.want {
+				t.Errorf("got %q, %v, want %q", data, err, tt.want)
+			}
+		})
+	}
+}
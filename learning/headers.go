@@ -0,0 +1,91 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// WithHeaders sends header with every request
+func WithHeaders(header http.Header) Option {
+	return func(s *SimpleScraper) {
+		s.header = header.Clone()
+	}
+}
+
+// WithContextHeaders derives request headers from the request context, for example
+// a tenant or trace id stored there. They replace static headers with the same name.
+func WithContextHeaders(fn func(ctx context.Context) http.Header) Option {
+	return func(s *SimpleScraper) {
+		s.contextHeader = fn
+	}
+}
+
+// WithHeaderProfiles sends the headers of a profile picked by rotator with every request
+func WithHeaderProfiles(rotator *ProfileRotator) Option {
+	return func(s *SimpleScraper) {
+		s.profiles = rotator
+	}
+}
+
+// ProfileRotator cycles through header profiles, for example the User-Agent and
+// Accept headers of different browsers
+type ProfileRotator struct {
+	// Select picks the profile for a host, when nil the profiles are used in turn.
+	// Returning nil falls back to the rotation.
+	Select func(host string, profiles []http.Header) http.Header
+
+	mu       sync.Mutex
+	profiles []http.Header
+	next     int
+}
+
+// NewProfileRotator creates a ProfileRotator over profiles
+func NewProfileRotator(profiles ...http.Header) *ProfileRotator {
+	return &ProfileRotator{profiles: profiles}
+}
+
+// NewUserAgentRotator creates a ProfileRotator with a profile per User-Agent
+func NewUserAgentRotator(userAgents ...string) *ProfileRotator {
+	profiles := make([]http.Header, 0, len(userAgents))
+	for _, ua := range userAgents {
+		profiles = append(profiles, http.Header{"User-Agent": {ua}})
+	}
+	return NewProfileRotator(profiles...)
+}
+
+// Profile returns the headers to use for a request to host
+func (r *ProfileRotator) Profile(host string) http.Header {
+	if len(r.profiles) == 0 {
+		return nil
+	}
+	if r.Select != nil {
+		if profile := r.Select(host, r.profiles); profile != nil {
+			return profile
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	profile := r.profiles[r.next]
+	r.next = (r.next + 1) % len(r.profiles)
+	return profile
+}
+
+// applyHeaders sets the static headers, then the header profile and last the ones
+// taken from the context, later ones replace earlier ones with the same name
+func (s *SimpleScraper) applyHeaders(req *http.Request) {
+	setHeaders(req.Header, s.header)
+	if s.profiles != nil {
+		setHeaders(req.Header, s.profiles.Profile(req.URL.Hostname()))
+	}
+	if s.contextHeader != nil {
+		setHeaders(req.Header, s.contextHeader(req.Context()))
+	}
+}
+
+func setHeaders(dst, src http.Header) {
+	for key, values := range src {
+		dst[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+}
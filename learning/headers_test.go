@@ -0,0 +1,61 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type tenantKey struct{}
+
+func TestContextHeaders(t *testing.T) {
+	got := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header
+	}))
+	defer srv.Close()
+
+	tenantHeaders := func(ctx context.Context) http.Header {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		if tenant == "" {
+			return nil
+		}
+		return http.Header{"X-Tenant": {tenant}}
+	}
+	static := WithHeaders(http.Header{"X-Tenant": {"default"}, "X-Static": {"yes"}})
+	withTenant := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		ctx        context.Context
+		sr         ScrapeRequest
+		wantTenant string
+	}{
+		{"static", []Option{static}, context.Background(), ScrapeRequest{}, "default"},
+		{"no value in context", []Option{static, WithContextHeaders(tenantHeaders)}, context.Background(), ScrapeRequest{}, "default"},
+		{"context replaces static", []Option{static, WithContextHeaders(tenantHeaders)}, withTenant, ScrapeRequest{}, "acme"},
+		{"request replaces context", []Option{static, WithContextHeaders(tenantHeaders)}, withTenant,
+			ScrapeRequest{Header: http.Header{"X-Tenant": {"explicit"}}}, "explicit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tt.sr
+			sr.URL = srv.URL
+			result := NewSimpleScraper(5*time.Second, tt.opts...).ScrapeWith(tt.ctx, sr)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			header := <-got
+			if tenant := header.Get("X-Tenant"); tenant != tt.wantTenant {
+				t.Errorf("X-Tenant = %q, want %q", tenant, tt.wantTenant)
+			}
+			// headers the context doesn't set are kept
+			if header.Get("X-Static") != "yes" {
+				t.Errorf("X-Static = %q, want yes", header.Get("X-Static"))
+			}
+		})
+	}
+}
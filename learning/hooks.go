@@ -0,0 +1,78 @@
+package learning
+
+import "net/http"
+
+// HookError is the error of a request that a RequestHook or ResponseHook
+// failed, such requests are not retried
+type HookError struct {
+	// Hook is "request" or "response"
+	Hook string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return e.Hook + " hook: " + e.Err.Error()
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// RequestHook sees every outgoing request right before it is sent and may
+// change it, an error cancels the request
+type RequestHook func(req *http.Request) error
+
+// ResponseHook sees every response before its status is checked and its body is
+// read, it must not read the body. An error fails the request.
+type ResponseHook func(resp *http.Response) error
+
+// ErrorHook is told about every failed attempt, Result.Attempts says which one it was
+type ErrorHook func(result Result)
+
+// WithOnRequest calls hook for every request, hooks run in the order they were added
+func WithOnRequest(hook RequestHook) Option {
+	return func(s *SimpleScraper) {
+		s.onRequest = append(s.onRequest, hook)
+	}
+}
+
+// WithOnResponse calls hook for every response, hooks run in the order they were added
+func WithOnResponse(hook ResponseHook) Option {
+	return func(s *SimpleScraper) {
+		s.onResponse = append(s.onResponse, hook)
+	}
+}
+
+// WithOnError calls hook for every failed attempt, including the ones retried
+func WithOnError(hook ErrorHook) Option {
+	return func(s *SimpleScraper) {
+		s.onError = append(s.onError, hook)
+	}
+}
+
+func (s *SimpleScraper) requestHooks(req *http.Request) error {
+	for _, hook := range s.onRequest {
+		if err := hook(req); err != nil {
+			return &HookError{Hook: "request", Err: err}
+		}
+	}
+	return nil
+}
+
+func (s *SimpleScraper) responseHooks(resp *http.Response) error {
+	for _, hook := range s.onResponse {
+		if err := hook(resp); err != nil {
+			return &HookError{Hook: "response", Err: err}
+		}
+	}
+	return nil
+}
+
+func (s *SimpleScraper) errorHooks(result Result) {
+	if result.Err == nil {
+		return
+	}
+	for _, hook := range s.onError {
+		hook(result)
+	}
+}
@@ -0,0 +1,299 @@
+package learning
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// JobStore remembers which urls of a crawl are queued and which are finished,
+// so a killed run can resume where it left off
+type JobStore interface {
+	// Queue records url as pending, a url that is already known keeps its state
+	Queue(ctx context.Context, job PriorityURL) error
+	// Finish marks url as finished
+	Finish(ctx context.Context, url string) error
+	// Pending returns the urls that were queued but never finished, in queue order
+	Pending(ctx context.Context) ([]PriorityURL, error)
+	// Finished reports whether url was finished
+	Finished(ctx context.Context, url string) (bool, error)
+}
+
+// WithJobStore keeps the state of every batch in jobs. A batch also scrapes the
+// urls jobs still holds as pending and skips the ones it holds as finished, so
+// running the same crawl again after a crash only fetches what is left. Urls
+// stopped by a cancelled batch stay pending.
+func WithJobStore(jobs JobStore) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Jobs = jobs
+	}
+}
+
+// Resume scrapes the urls c.Jobs holds as pending, including the ones an
+// earlier run discovered but never got to
+func (c *ConcurrentScraper) Resume(ctx context.Context) ([]Result, error) {
+	if c.Jobs == nil {
+		return nil, errors.New("resume needs a job store")
+	}
+	return c.ScrapePriority(ctx, nil)
+}
+
+// pendingJobs adds the urls c.Jobs holds as pending to urls, leaving out the
+// ones urls already lists
+func (c *ConcurrentScraper) pendingJobs(ctx context.Context, urls []PriorityURL) ([]PriorityURL, error) {
+	pending, err := c.Jobs.Pending(ctx)
+	if err != nil {
+		return urls, err
+	}
+
+	listed := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		listed[normalizeOrRaw(u.URL)] = true
+	}
+	for _, job := range pending {
+		if !listed[normalizeOrRaw(job.URL)] {
+			urls = append(urls, job)
+		}
+	}
+	return urls, nil
+}
+
+// queueJob records url as pending in c.Jobs, a failure is reported by the batch
+func (c *ConcurrentScraper) queueJob(b *batch, url string, priority int) {
+	if c.Jobs == nil {
+		return
+	}
+	if err := c.Jobs.Queue(context.WithoutCancel(b.ctx), PriorityURL{URL: url, Priority: priority}); err != nil {
+		b.fail(err)
+	}
+}
+
+// finishJob marks url as finished in c.Jobs unless the batch stopped it, those
+// are picked up again by the next run. url is the queued url, result.URL may
+// have its credentials redacted.
+func (c *ConcurrentScraper) finishJob(b *batch, url string, result Result) {
+	if c.Jobs == nil {
+		return
+	}
+	if b.ctx.Err() != nil && (errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded)) {
+		return
+	}
+	if err := c.Jobs.Finish(context.WithoutCancel(b.ctx), url); err != nil {
+		b.fail(err)
+	}
+}
+
+// jobEntry is a line of the FileJobStore journal
+type jobEntry struct {
+	URL      string `json:"url"`
+	Priority int    `json:"priority,omitempty"`
+	Done     bool   `json:"done,omitempty"`
+}
+
+// FileJobStore keeps the job state in a journal file with one JSON line per
+// change. It only appends, so a crash loses at most the line being written.
+type FileJobStore struct {
+	mu    sync.Mutex
+	file  *os.File
+	order []string
+	jobs  map[string]*jobEntry
+}
+
+// OpenFileJobStore opens the journal at path, creating it if it doesn't exist,
+// and replays it. A torn last line from a crash is cut off, so the next line
+// appended doesn't end up joined to it.
+func OpenFileJobStore(path string) (*FileJobStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job journal: %w", err)
+	}
+
+	f := &FileJobStore{file: file, jobs: make(map[string]*jobEntry)}
+	if err := f.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// replay applies the complete lines of the journal and truncates it after the last one
+func (f *FileJobStore) replay() error {
+	r := bufio.NewReader(f.file)
+	var complete int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read job journal: %w", err)
+		}
+		complete += int64(len(line))
+
+		var entry jobEntry
+		if json.Unmarshal(line, &entry) != nil || entry.URL == "" {
+			continue
+		}
+		f.apply(entry)
+	}
+
+	if err := f.file.Truncate(complete); err != nil {
+		return fmt.Errorf("failed to cut torn line off job journal: %w", err)
+	}
+	return nil
+}
+
+func (f *FileJobStore) apply(entry jobEntry) {
+	job, ok := f.jobs[entry.URL]
+	if !ok {
+		job = &jobEntry{URL: entry.URL, Priority: entry.Priority}
+		f.jobs[entry.URL] = job
+		f.order = append(f.order, entry.URL)
+	}
+	job.Done = job.Done || entry.Done
+}
+
+func (f *FileJobStore) write(entry jobEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", entry.URL, err)
+	}
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write job %s: %w", entry.URL, err)
+	}
+	f.apply(entry)
+	return nil
+}
+
+// Queue implements JobStore
+func (f *FileJobStore) Queue(ctx context.Context, job PriorityURL) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.jobs[job.URL]; ok {
+		return nil
+	}
+	return f.write(jobEntry{URL: job.URL, Priority: job.Priority})
+}
+
+// Finish implements JobStore
+func (f *FileJobStore) Finish(ctx context.Context, url string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if job, ok := f.jobs[url]; ok && job.Done {
+		return nil
+	}
+	return f.write(jobEntry{URL: url, Done: true})
+}
+
+// Pending implements JobStore
+func (f *FileJobStore) Pending(ctx context.Context) ([]PriorityURL, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var pending []PriorityURL
+	for _, url := range f.order {
+		if job := f.jobs[url]; !job.Done {
+			pending = append(pending, PriorityURL{URL: url, Priority: job.Priority})
+		}
+	}
+	return pending, nil
+}
+
+// Finished implements JobStore
+func (f *FileJobStore) Finished(ctx context.Context, url string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.jobs[url]
+	return ok && job.Done, nil
+}
+
+// Close closes the journal
+func (f *FileJobStore) Close() error {
+	return f.file.Close()
+}
+
+// SQLiteJobStore keeps the job state in the jobs table of a SQLite database,
+// like SQLiteStore the caller picks the driver
+type SQLiteJobStore struct {
+	DB *sql.DB
+}
+
+// NewSQLiteJobStore creates a SQLiteJobStore and the jobs table if it doesn't exist
+func NewSQLiteJobStore(ctx context.Context, db *sql.DB) (*SQLiteJobStore, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL UNIQUE,
+		priority INTEGER NOT NULL,
+		done INTEGER NOT NULL DEFAULT 0
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jobs table: %w", err)
+	}
+	return &SQLiteJobStore{DB: db}, nil
+}
+
+// Queue implements JobStore
+func (s *SQLiteJobStore) Queue(ctx context.Context, job PriorityURL) error {
+	_, err := s.DB.ExecContext(ctx,
+		`INSERT INTO jobs (url, priority) VALUES (?, ?) ON CONFLICT (url) DO NOTHING`,
+		job.URL, job.Priority)
+	if err != nil {
+		return fmt.Errorf("failed to queue %s: %w", job.URL, err)
+	}
+	return nil
+}
+
+// Finish implements JobStore
+func (s *SQLiteJobStore) Finish(ctx context.Context, url string) error {
+	_, err := s.DB.ExecContext(ctx,
+		`INSERT INTO jobs (url, priority, done) VALUES (?, 0, 1) ON CONFLICT (url) DO UPDATE SET done = 1`,
+		url)
+	if err != nil {
+		return fmt.Errorf("failed to finish %s: %w", url, err)
+	}
+	return nil
+}
+
+// Pending implements JobStore
+func (s *SQLiteJobStore) Pending(ctx context.Context) ([]PriorityURL, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT url, priority FROM jobs WHERE done = 0 ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pending jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []PriorityURL
+	for rows.Next() {
+		var job PriorityURL
+		if err := rows.Scan(&job.URL, &job.Priority); err != nil {
+			return nil, fmt.Errorf("failed to load pending jobs: %w", err)
+		}
+		pending = append(pending, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load pending jobs: %w", err)
+	}
+	return pending, nil
+}
+
+// Finished implements JobStore
+func (s *SQLiteJobStore) Finished(ctx context.Context, url string) (bool, error) {
+	var done bool
+	err := s.DB.QueryRowContext(ctx, `SELECT done FROM jobs WHERE url = ?`, url).Scan(&done)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s: %w", url, err)
+	}
+	return done, nil
+}
@@ -0,0 +1,74 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileJobStoreTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	journal := `{"url":"https://a.com/"}` + "\n" + `{"url":"https://b.co`
+	if err := os.WriteFile(path, []byte(journal), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := store.Queue(ctx, PriorityURL{URL: "https://c.com/"}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// the job queued after the torn line must survive the next open
+	store, err = OpenFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	pending, err := store.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PriorityURL{{URL: "https://a.com/"}, {URL: "https://c.com/"}}
+	if !slices.Equal(pending, want) {
+		t.Errorf("Pending() = %v, want %v", pending, want)
+	}
+}
+
+func TestJobsFinishQueuedURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+	defer srv.Close()
+
+	store, err := OpenFileJobStore(filepath.Join(t.TempDir(), "jobs.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// the result reports the url redacted, the job must still be finished
+	url := strings.Replace(srv.URL, "http://", "http://user:secret@", 1) + "/"
+	c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 1, WithJobStore(store))
+	if _, err := c.ScrapeWithError(context.Background(), []string{url}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if finished, _ := store.Finished(ctx, url); !finished {
+		t.Errorf("%s is not finished", url)
+	}
+	if pending, _ := store.Pending(ctx); len(pending) != 0 {
+		t.Errorf("Pending() = %v, want none", pending)
+	}
+}
@@ -0,0 +1,71 @@
+package learning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrNotJSON is returned by ScrapeJSON for responses that aren't JSON
+var ErrNotJSON = errors.New("response is not json")
+
+// requestScraper is implemented by scrapers that take a full ScrapeRequest
+type requestScraper interface {
+	ScrapeWith(ctx context.Context, sr ScrapeRequest) Result
+}
+
+// ScrapeJSON fetches url and decodes the JSON body into a T. When the scraper
+// reports response headers the Content-Type must be JSON, otherwise the body
+// has to be valid JSON. Bodies that are JSON but don't fit T give a decode error,
+// a 304 answer to a conditional request gives ErrNotModified.
+func ScrapeJSON[T any](ctx context.Context, scraper Scraper, url string) (T, error) {
+	var v T
+
+	result := fetchJSON(ctx, scraper, url)
+	if result.Err != nil {
+		return v, result.Err
+	}
+	if result.NotModified {
+		return v, fmt.Errorf("%w: %s", ErrNotModified, result.URL)
+	}
+	if err := checkJSON(result); err != nil {
+		return v, err
+	}
+
+	if err := json.Unmarshal(result.Data, &v); err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to decode json from %s: %w", url, err)
+	}
+	return v, nil
+}
+
+// fetchJSON asks for JSON when the scraper lets us set headers
+func fetchJSON(ctx context.Context, scraper Scraper, url string) Result {
+	switch s := scraper.(type) {
+	case requestScraper:
+		return s.ScrapeWith(ctx, ScrapeRequest{URL: url, Header: http.Header{"Accept": {"application/json"}}})
+	case ResultScraper:
+		return s.ScrapeResult(ctx, url)
+	}
+	data, err := scraper.Scrape(ctx, url)
+	return Result{URL: url, Data: data, Err: err}
+}
+
+func checkJSON(result Result) error {
+	if contentType := result.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return nil
+		}
+		return fmt.Errorf("%w: %s has content type %q", ErrNotJSON, result.URL, contentType)
+	}
+
+	if !json.Valid(result.Data) {
+		return fmt.Errorf("%w: %s", ErrNotJSON, result.URL)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScrapeJSON(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        item
+		wantErr     error
+	}{
+		{"json", "application/json", `{"name":"a"}`, item{Name: "a"}, nil},
+		{"json suffix", "application/ld+json; charset=utf-8", `{"name":"b"}`, item{Name: "b"}, nil},
+		{"html", "text/html", `{"name":"c"}`, item{}, ErrNotJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			got, err := ScrapeJSON[item](context.Background(), NewSimpleScraper(5*time.Second), srv.URL)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ScrapeJSON() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScrapeJSONNotModified(t *testing.T) {
+	srv := etagServer(t, "application/json", `{"name":"a"}`)
+	s := NewSimpleScraper(5*time.Second, WithConditionalRequests(&mapValidators{}))
+
+	if _, err := ScrapeJSON[map[string]string](context.Background(), s, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ScrapeJSON[map[string]string](context.Background(), s, srv.URL)
+	if !errors.Is(err, ErrNotModified) || got != nil {
+		t.Errorf("second ScrapeJSON() = %v, %v, want ErrNotModified", got, err)
+	}
+}
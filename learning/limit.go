@@ -0,0 +1,56 @@
+package learning
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithMaxBodyBytes fails responses whose decoded body is larger than n bytes
+// with ErrTooLarge, instead of reading them into memory completely
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *SimpleScraper) {
+		s.maxBodyBytes = n
+	}
+}
+
+// limitBody caps body at s.maxBodyBytes, a response announcing a larger
+// Content-Length fails right away
+func (s *SimpleScraper) limitBody(body io.ReadCloser, contentLength int64, url string) (io.ReadCloser, error) {
+	if s.maxBodyBytes <= 0 {
+		return body, nil
+	}
+	if contentLength > s.maxBodyBytes {
+		return nil, fmt.Errorf("%w: %s has %d bytes, limit is %d", ErrTooLarge, url, contentLength, s.maxBodyBytes)
+	}
+	return &limitedBody{
+		Reader: io.LimitReader(body, s.maxBodyBytes+1),
+		body:   body,
+		limit:  s.maxBodyBytes,
+		url:    url,
+	}, nil
+}
+
+// limitedBody fails with ErrTooLarge once more than limit bytes were read
+type limitedBody struct {
+	io.Reader
+	body  io.ReadCloser
+	limit int64
+	read  int64
+	url   string
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	n, err := l.Reader.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		// hand out exactly limit bytes so a streaming consumer never sees more
+		n -= int(l.read - l.limit)
+		l.read = l.limit
+		return n, fmt.Errorf("%w: %s exceeds %d bytes", ErrTooLarge, l.url, l.limit)
+	}
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
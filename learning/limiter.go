@@ -0,0 +1,92 @@
+package learning
+
+import (
+	"context"
+	"sync"
+)
+
+// ConcurrencyLimiter caps how many scrapes may run at the same time,
+// one limiter can be shared by several ConcurrentScrapers
+type ConcurrencyLimiter interface {
+	Acquire(ctx context.Context) error
+	Release()
+}
+
+// Semaphore is a ConcurrencyLimiter backed by a buffered channel
+type Semaphore chan struct{}
+
+// NewSemaphore creates a Semaphore that allows n concurrent holders
+func NewSemaphore(n int) Semaphore {
+	return make(Semaphore, n)
+}
+
+// Acquire blocks until a slot is free or the context is done
+func (s Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (s Semaphore) Release() {
+	<-s
+}
+
+// HostLimiter caps how many scrapes of the same host may run at the same time,
+// one limiter can be shared by several ConcurrentScrapers
+type HostLimiter struct {
+	PerHost int
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+// hostSlots is the semaphore of a host, it is dropped once nobody uses it
+type hostSlots struct {
+	sem   Semaphore
+	users int
+}
+
+// NewHostLimiter creates a HostLimiter that allows perHost concurrent scrapes per host
+func NewHostLimiter(perHost int) *HostLimiter {
+	return &HostLimiter{PerHost: perHost, hosts: make(map[string]*hostSlots)}
+}
+
+// Acquire blocks until host has a free slot or the context is done
+func (h *HostLimiter) Acquire(ctx context.Context, host string) error {
+	h.mu.Lock()
+	slots, ok := h.hosts[host]
+	if !ok {
+		slots = &hostSlots{sem: NewSemaphore(max(h.PerHost, 1))}
+		h.hosts[host] = slots
+	}
+	slots.users++
+	h.mu.Unlock()
+
+	if err := slots.sem.Acquire(ctx); err != nil {
+		h.leave(host, slots)
+		return err
+	}
+	return nil
+}
+
+// Release frees a slot of host taken by Acquire
+func (h *HostLimiter) Release(host string) {
+	h.mu.Lock()
+	slots := h.hosts[host]
+	h.mu.Unlock()
+
+	slots.sem.Release()
+	h.leave(host, slots)
+}
+
+func (h *HostLimiter) leave(host string, slots *hostSlots) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if slots.users--; slots.users == 0 {
+		delete(h.hosts, host)
+	}
+}
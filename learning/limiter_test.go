@@ -0,0 +1,49 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSharedConcurrencyLimiter(t *testing.T) {
+	var running, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	// each scraper could run 4 at once on its own, together they get 2
+	limiter := NewSemaphore(2)
+	var wg sync.WaitGroup
+	for i := range 2 {
+		c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 4, WithConcurrencyLimiter(limiter))
+		var urls []string
+		for j := range 6 {
+			urls = append(urls, fmt.Sprintf("%s/%d/%d", srv.URL, i, j))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, result := range c.Scrape(context.Background(), urls) {
+				if result.Err != nil {
+					t.Error(result.Err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d requests ran at once, want at most 2", p)
+	}
+}
@@ -0,0 +1,145 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"test/learning/extract"
+)
+
+// Logger is the structured logger used by the scraper, *slog.Logger satisfies it
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// WithLogger sets the logger used by the ConcurrentScraper
+func WithLogger(logger Logger) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Logger = logger
+	}
+}
+
+// WithSlowRequestThreshold logs every fetch that takes longer than d, measured
+// the same way as Result.Duration
+func WithSlowRequestThreshold(d time.Duration) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.SlowThreshold = d
+	}
+}
+
+func (c *ConcurrentScraper) logger() Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+// ErrorClass sorts an error into a coarse class for stats and logging
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrTimeout), isTimeout(err):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrNotRecorded):
+		return "not_found"
+	case errors.Is(err, ErrFiltered):
+		return "filtered"
+	case errors.Is(err, ErrSkipped):
+		return "skipped"
+	case errors.Is(err, ErrRobotsDisallowed):
+		return "robots"
+	case errors.Is(err, ErrTooLarge):
+		return "too_large"
+	case errors.Is(err, ErrBadStatus{}):
+		return "status"
+	default:
+		return "other"
+	}
+}
+
+// LogSummary logs the summary of a run as key/value pairs
+func LogSummary(logger Logger, stats Stats) {
+	args := []any{
+		"total", stats.Total,
+		"succeeded", stats.Succeeded,
+		"failed", stats.Failed,
+		"bytes", stats.Bytes,
+		"fetch_time", stats.FetchTime,
+		"elapsed", stats.Elapsed,
+		"parallelism", stats.Parallelism(),
+	}
+
+	classes := make([]string, 0, len(stats.Errors))
+	for class := range stats.Errors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		args = append(args, "errors_"+class, stats.Errors[class])
+	}
+
+	logger.Info("scrape summary", args...)
+}
+
+// ResultLogger logs every result with its url, status, size, duration and error
+type ResultLogger struct {
+	Logger *slog.Logger
+	// Level is used for successful results and extracted fields
+	Level slog.Level
+	// ErrorLevel is used for failed results and failed extractions
+	ErrorLevel slog.Level
+}
+
+// NewResultLogger creates a ResultLogger that logs successes at info and failures
+// at warn, slog.Default() is used when logger is nil
+func NewResultLogger(logger *slog.Logger) *ResultLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ResultLogger{Logger: logger, Level: slog.LevelInfo, ErrorLevel: slog.LevelWarn}
+}
+
+// Log logs a single result
+func (l *ResultLogger) Log(ctx context.Context, result Result) {
+	attrs := []slog.Attr{
+		slog.String("url", result.URL),
+		slog.Int("status", result.StatusCode),
+		slog.Int("bytes", len(result.Data)),
+		slog.Duration("duration", result.Duration),
+	}
+	if result.Err != nil {
+		attrs = append(attrs, slog.Any("error", result.Err))
+		l.Logger.LogAttrs(ctx, l.ErrorLevel, "fetch failed", attrs...)
+		return
+	}
+	l.Logger.LogAttrs(ctx, l.Level, "fetched", attrs...)
+}
+
+// Process logs every result and the fields extractors pull out of the fetched pages
+func (l *ResultLogger) Process(ctx context.Context, results []Result, extractors ...extract.Extractor) {
+	for _, result := range results {
+		l.Log(ctx, result)
+		if result.Err != nil {
+			continue
+		}
+
+		for _, extractor := range extractors {
+			fields, err := extractor.Extract(result.Data)
+			if err != nil {
+				l.Logger.LogAttrs(ctx, l.ErrorLevel, "extract failed", slog.String("url", result.URL), slog.Any("error", err))
+				continue
+			}
+			for name, values := range fields {
+				l.Logger.LogAttrs(ctx, l.Level, "extracted", slog.String("url", result.URL), slog.String("field", name), slog.Any("values", values))
+			}
+		}
+	}
+}
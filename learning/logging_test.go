@@ -0,0 +1,87 @@
+package learning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogSummary(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	stats := NewStatsCollector()
+	stats.Add(Result{URL: "https://a.com/", Data: []byte("page"), Duration: time.Second})
+	stats.Add(Result{URL: "https://a.com/x", Err: ErrBadStatus{Code: 404}, Duration: time.Second})
+	stats.Add(Result{URL: "https://b.com/", Err: ErrBadStatus{Code: 500}, Duration: time.Second})
+	stats.Add(Result{URL: "https://b.com/y", Err: ErrNotFound, Duration: time.Second})
+	LogSummary(logger, stats.Stats())
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("%v in %s", err, buf.String())
+	}
+	want := map[string]any{
+		"msg":              "scrape summary",
+		"level":            "INFO",
+		"total":            4.0,
+		"succeeded":        1.0,
+		"failed":           3.0,
+		"bytes":            4.0,
+		"fetch_time":       float64(4 * time.Second),
+		"errors_status":    2.0,
+		"errors_not_found": 1.0,
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	for _, key := range []string{"elapsed", "parallelism"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("%s is missing from %s", key, buf.String())
+		}
+	}
+}
+
+func TestSlowRequestThreshold(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		wantSlow  []string
+	}{
+		{"off", 0, nil},
+		{"only slow", 50 * time.Millisecond, []string{"/slow"}},
+		{"both under", time.Minute, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 2,
+				WithLogger(logger), WithSlowRequestThreshold(tt.threshold))
+			c.Scrape(context.Background(), []string{srv.URL + "/fast", srv.URL + "/slow"})
+
+			if len(logger.messages) != len(tt.wantSlow) {
+				t.Fatalf("logged %q, want %d slow requests", logger.messages, len(tt.wantSlow))
+			}
+			for i, path := range tt.wantSlow {
+				if msg := logger.messages[i]; !strings.HasPrefix(msg, "WARN slow request") || !strings.Contains(msg, srv.URL+path) {
+					t.Errorf("logged %q, want a slow request warning for %s", msg, path)
+				}
+			}
+		})
+	}
+}
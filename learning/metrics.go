@@ -0,0 +1,86 @@
+package learning
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics counts requests, errors, bytes and latency for Prometheus. It is a
+// prometheus.Collector, register it once and share it between scrapers.
+// The source label is "http" for the requests of a SimpleScraper, retries
+// included, and "batch" for the results of a ConcurrentScraper.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewMetrics creates Metrics whose names start with namespace, such as "scraper"
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Scrapes started.",
+		}, []string{"source"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Failed scrapes by error class.",
+		}, []string{"source", "class"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fetched_bytes_total",
+			Help:      "Body bytes fetched.",
+		}, []string{"source"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "duration_seconds",
+			Help:      "Time spent per scrape.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"source"}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.errors.Describe(ch)
+	m.bytes.Describe(ch)
+	m.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.errors.Collect(ch)
+	m.bytes.Collect(ch)
+	m.latency.Collect(ch)
+}
+
+// observe records a finished scrape
+func (m *Metrics) observe(source string, result Result, d time.Duration) {
+	m.requests.WithLabelValues(source).Inc()
+	m.latency.WithLabelValues(source).Observe(d.Seconds())
+	if result.Err != nil {
+		m.errors.WithLabelValues(source, ErrorClass(result.Err)).Inc()
+		return
+	}
+	m.bytes.WithLabelValues(source).Add(float64(len(result.Data)))
+}
+
+// WithMetrics records every request of the SimpleScraper in m
+func WithMetrics(m *Metrics) Option {
+	return func(s *SimpleScraper) {
+		s.metrics = m
+	}
+}
+
+// WithBatchMetrics records every result of the ConcurrentScraper in m
+func WithBatchMetrics(m *Metrics) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Metrics = m
+	}
+}
@@ -0,0 +1,198 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a Scraper with extra behaviour
+type Middleware func(Scraper) Scraper
+
+// Chain wraps scraper with middleware, the first middleware is the outermost,
+// so Chain(s, Logged(l), Cached(c, ttl)) logs every call including cache hits
+func Chain(scraper Scraper, middleware ...Middleware) Scraper {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		scraper = middleware[i](scraper)
+	}
+	return scraper
+}
+
+// Cached serves repeated urls from cache, see CachingScraper
+func Cached(cache Cache, ttl time.Duration) Middleware {
+	return func(next Scraper) Scraper {
+		return NewCachingScraper(next, cache, ttl)
+	}
+}
+
+// RobotsAware skips urls robots.txt disallows for userAgent, see RobotsAwareScraper
+func RobotsAware(userAgent string) Middleware {
+	return func(next Scraper) Scraper {
+		return NewRobotsAwareScraper(next, userAgent)
+	}
+}
+
+// Coalesced joins concurrent scrapes of the same url, see SingleflightScraper
+func Coalesced() Middleware {
+	return func(next Scraper) Scraper {
+		return NewSingleflightScraper(next)
+	}
+}
+
+// Retried retries failed scrapes following policy, see RetryingScraper
+func Retried(policy RetryPolicy) Middleware {
+	return func(next Scraper) Scraper {
+		return NewRetryingScraper(next, policy)
+	}
+}
+
+// Logged logs every scrape to logger, see LoggingScraper
+func Logged(logger Logger) Middleware {
+	return func(next Scraper) Scraper {
+		return &LoggingScraper{Scraper: next, Logger: logger}
+	}
+}
+
+// Throttled starts at most one scrape per interval, see ThrottledScraper
+func Throttled(interval time.Duration) Middleware {
+	return func(next Scraper) Scraper {
+		return &ThrottledScraper{Scraper: next, Interval: interval}
+	}
+}
+
+// RetryingScraper retries any scraper. Every failure is retried except the
+// ones that would fail again, such as a cancelled context or a 404.
+type RetryingScraper struct {
+	Scraper Scraper
+	Policy  RetryPolicy
+	// Clock times the delays, RealClock when nil
+	Clock Clock
+
+	rand *lockedRand
+}
+
+// NewRetryingScraper creates a RetryingScraper
+func NewRetryingScraper(scraper Scraper, policy RetryPolicy) *RetryingScraper {
+	return &RetryingScraper{
+		Scraper: scraper,
+		Policy:  policy,
+		rand:    newLockedRand(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Name identifies the RetryingScraper and the scraper it wraps
+func (r *RetryingScraper) Name() string {
+	return "retry(" + ScraperName(r.Scraper) + ")"
+}
+
+// Scrape fetches url, retrying up to Policy.MaxAttempts times
+func (r *RetryingScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	clock := r.Clock
+	if clock == nil {
+		clock = RealClock
+	}
+
+	for attempt := 1; ; attempt++ {
+		data, err := r.Scraper.Scrape(ctx, url)
+		if attempt >= r.Policy.MaxAttempts || !retryable(err) {
+			return data, err
+		}
+
+		select {
+		case <-clock.After(r.Policy.delay(attempt, r.rand)):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (after %d attempts: %w)", ctx.Err(), attempt, err)
+		}
+	}
+}
+
+// Close closes the wrapped scraper
+func (r *RetryingScraper) Close() error {
+	return closeScraper(r.Scraper)
+}
+
+// LoggingScraper logs the outcome and duration of every scrape
+type LoggingScraper struct {
+	Scraper Scraper
+	Logger  Logger
+}
+
+// Name identifies the LoggingScraper and the scraper it wraps
+func (l *LoggingScraper) Name() string {
+	return "log(" + ScraperName(l.Scraper) + ")"
+}
+
+// Scrape fetches url and logs how it went
+func (l *LoggingScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	start := time.Now()
+	data, err := l.Scraper.Scrape(ctx, url)
+	if err != nil {
+		l.Logger.Warn("scrape failed", "url", url, "duration", time.Since(start), "error", err)
+	} else {
+		l.Logger.Info("scraped", "url", url, "duration", time.Since(start), "bytes", len(data))
+	}
+	return data, err
+}
+
+// Close closes the wrapped scraper
+func (l *LoggingScraper) Close() error {
+	return closeScraper(l.Scraper)
+}
+
+// ThrottledScraper spaces the start of its scrapes at least Interval apart
+type ThrottledScraper struct {
+	Scraper  Scraper
+	Interval time.Duration
+	// Clock times the waits, RealClock when nil
+	Clock Clock
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// Name identifies the ThrottledScraper and the scraper it wraps
+func (t *ThrottledScraper) Name() string {
+	return "throttle(" + ScraperName(t.Scraper) + ")"
+}
+
+// Scrape waits for the next free slot and fetches url
+func (t *ThrottledScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	clock := t.Clock
+	if clock == nil {
+		clock = RealClock
+	}
+
+	// reserve a slot first so concurrent callers queue up behind each other
+	t.mu.Lock()
+	now := clock.Now()
+	slot := t.next
+	if slot.Before(now) {
+		slot = now
+	}
+	t.next = slot.Add(t.Interval)
+	t.mu.Unlock()
+
+	if wait := slot.Sub(now); wait > 0 {
+		select {
+		case <-clock.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return t.Scraper.Scrape(ctx, url)
+}
+
+// Reset forgets the reserved slots, so the next scrape starts right away.
+// Scrapes already waiting for their slot keep it.
+func (t *ThrottledScraper) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.next = time.Time{}
+}
+
+// Close closes the wrapped scraper
+func (t *ThrottledScraper) Close() error {
+	return closeScraper(t.Scraper)
+}
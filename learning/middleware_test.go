@@ -0,0 +1,24 @@
+package learning
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThrottledScraperReset(t *testing.T) {
+	stub := NewStubScraper(map[string][]byte{"https://example.com/": []byte("page")})
+	throttled := &ThrottledScraper{Scraper: stub, Interval: time.Hour, Clock: NewMockClock(time.Now())}
+
+	if _, err := throttled.Scrape(context.Background(), "https://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the mock clock never moves, a scrape that waits for its slot times out
+	throttled.Reset()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := throttled.Scrape(ctx, "https://example.com/"); err != nil {
+		t.Fatalf("got %v after Reset, want the scrape to start right away", err)
+	}
+}
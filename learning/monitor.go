@@ -0,0 +1,165 @@
+package learning
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Change is emitted by a Monitor when the content of a url changed
+type Change struct {
+	URL  string
+	Time time.Time
+	// OldHash and NewHash are the sha256 of the normalized content
+	OldHash string
+	NewHash string
+	// Diff is the unified diff of the normalized content
+	Diff   string
+	Result Result
+}
+
+// Monitor re-scrapes urls every Interval and reports when their content changes.
+// The first scrape of a url only records it.
+type Monitor struct {
+	Scraper  Scraper
+	Interval time.Duration
+	// Normalize strips dynamic noise such as timestamps or csrf tokens before
+	// the content is compared, see StripNoise
+	Normalize func([]byte) []byte
+	// Clock times the rounds, RealClock when nil
+	Clock  Clock
+	Logger Logger
+
+	mu   sync.Mutex
+	last map[string]snapshot
+}
+
+// snapshot is the last known content of a url
+type snapshot struct {
+	hash    string
+	content string
+	time    time.Time
+}
+
+// MonitorOption configures a Monitor
+type MonitorOption func(*Monitor)
+
+// WithNormalize compares content after normalize has stripped what changes on every load
+func WithNormalize(normalize func([]byte) []byte) MonitorOption {
+	return func(m *Monitor) {
+		m.Normalize = normalize
+	}
+}
+
+// NewMonitor creates a Monitor that scrapes with scraper every interval
+func NewMonitor(scraper Scraper, interval time.Duration, opts ...MonitorOption) *Monitor {
+	m := &Monitor{Scraper: scraper, Interval: interval}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// StripNoise removes every match of patterns, for content that changes on every
+// load without mattering
+func StripNoise(patterns ...*regexp.Regexp) func([]byte) []byte {
+	return func(data []byte) []byte {
+		for _, p := range patterns {
+			data = p.ReplaceAll(data, nil)
+		}
+		return data
+	}
+}
+
+// Watch checks urls right away and then every Interval until ctx is done, the
+// returned channel receives the changes and is closed when watching stops.
+// Failed scrapes are logged and keep the last known content.
+func (m *Monitor) Watch(ctx context.Context, urls []string) <-chan Change {
+	out := make(chan Change)
+	clock := m.Clock
+	if clock == nil {
+		clock = RealClock
+	}
+
+	go func() {
+		defer close(out)
+		for {
+			for _, url := range urls {
+				change, changed, err := m.Check(ctx, url)
+				if err != nil {
+					if ctx.Err() == nil {
+						m.logger().Warn("monitor check failed", "url", url, "error", err)
+					}
+					continue
+				}
+				if !changed {
+					continue
+				}
+				select {
+				case out <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-clock.After(m.Interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Check scrapes url once and compares it with the last known content, changed
+// is false for the first scrape of a url
+func (m *Monitor) Check(ctx context.Context, url string) (change Change, changed bool, err error) {
+	result := scrapeOne(ctx, m.Scraper, url)
+	if result.Err != nil {
+		return Change{}, false, result.Err
+	}
+
+	data := result.Data
+	if m.Normalize != nil {
+		data = m.Normalize(data)
+	}
+	sum := sha256.Sum256(data)
+	now := result.Started.Add(result.Duration)
+	current := snapshot{hash: hex.EncodeToString(sum[:]), content: string(data), time: now}
+
+	m.mu.Lock()
+	if m.last == nil {
+		m.last = make(map[string]snapshot)
+	}
+	previous, seen := m.last[url]
+	m.last[url] = current
+	m.mu.Unlock()
+
+	if !seen || previous.hash == current.hash {
+		return Change{}, false, nil
+	}
+	return Change{
+		URL:     url,
+		Time:    now,
+		OldHash: previous.hash,
+		NewHash: current.hash,
+		Diff: UnifiedDiff(
+			fmt.Sprintf("%s\t%s", url, previous.time.Format(time.RFC3339)),
+			fmt.Sprintf("%s\t%s", url, current.time.Format(time.RFC3339)),
+			previous.content, current.content),
+		Result: result,
+	}, true, nil
+}
+
+func (m *Monitor) logger() Logger {
+	if m.Logger == nil {
+		return slog.Default()
+	}
+	return m.Logger
+}
@@ -0,0 +1,102 @@
+package learning
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Normalize brings a url into a canonical form so equal pages compare equal:
+// scheme and host are lowercased, default ports, the fragment and dot segments
+// in the path are removed and query parameters are sorted by name
+func Normalize(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if host, port, err := net.SplitHostPort(u.Host); err == nil {
+		if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = host
+			if strings.Contains(host, ":") {
+				u.Host = "[" + host + "]"
+			}
+		}
+	}
+	u.Fragment, u.RawFragment = "", ""
+
+	if u.Host != "" && u.Path == "" {
+		u.Path = "/"
+	}
+	// resolving the path against itself removes . and .. segments
+	u = u.ResolveReference(&url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery})
+
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode()
+	}
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// normalizeOrRaw normalizes rawURL, urls that don't parse are kept as they are
+// so the filters can report them
+func normalizeOrRaw(rawURL string) string {
+	if normalized, err := Normalize(rawURL); err == nil {
+		return normalized
+	}
+	return rawURL
+}
+
+// Deduper remembers the normalized urls it has seen, it is safe for concurrent use.
+// Share one between batches with WithDeduper to never scrape a url twice.
+type Deduper struct {
+	mu  sync.Mutex
+	set URLSet
+}
+
+// NewDeduper creates an empty Deduper that remembers urls exactly
+func NewDeduper() *Deduper {
+	return NewDeduperWith(mapSet{})
+}
+
+// NewDeduperWith creates a Deduper that keeps its urls in set
+func NewDeduperWith(set URLSet) *Deduper {
+	return &Deduper{set: set}
+}
+
+// Add records url and reports whether it was new
+func (d *Deduper) Add(url string) bool {
+	key := normalizeOrRaw(url)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.set.Add(key)
+}
+
+// Seen reports whether url was added before
+func (d *Deduper) Seen(url string) bool {
+	key := normalizeOrRaw(url)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.set.Has(key)
+}
+
+// Len returns the number of distinct urls seen
+func (d *Deduper) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.set.Len()
+}
+
+// WithDeduper makes every batch skip the urls d has seen, including the ones
+// scraped by earlier batches
+func WithDeduper(d *Deduper) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Deduper = d
+	}
+}
@@ -0,0 +1,98 @@
+package learning
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://example.com", "https://example.com/"},
+		{"HTTPS://Example.COM/Path", "https://example.com/Path"},
+		{"http://example.com:80/", "http://example.com/"},
+		{"https://example.com:443/", "https://example.com/"},
+		{"https://example.com:8443/", "https://example.com:8443/"},
+		{"http://[::1]:80/", "http://[::1]/"},
+		{"https://example.com/page#section", "https://example.com/page"},
+		{"https://example.com/a/./b/../c", "https://example.com/a/c"},
+		{"https://example.com/?b=2&a=1", "https://example.com/?a=1&b=2"},
+		{"https://example.com/?", "https://example.com/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Normalize("http://[::1"); err == nil {
+		t.Error("Normalize of an invalid url succeeded")
+	}
+}
+
+func TestDeduper(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func() *Deduper
+	}{
+		{"exact", NewDeduper},
+		{"bloom", func() *Deduper { return NewBloomDeduper(1000, 0.001) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.new()
+			if !d.Add("https://example.com") {
+				t.Error("first Add reported a duplicate")
+			}
+			// equal after normalization
+			for _, url := range []string{"https://example.com/", "HTTPS://EXAMPLE.COM:443/#top"} {
+				if d.Add(url) {
+					t.Errorf("Add(%q) reported a new url", url)
+				}
+				if !d.Seen(url) {
+					t.Errorf("Seen(%q) = false", url)
+				}
+			}
+			if d.Seen("https://example.org") {
+				t.Error("Seen reported a url that was never added")
+			}
+			if d.Len() != 1 {
+				t.Errorf("Len() = %d, want 1", d.Len())
+			}
+		})
+	}
+}
+
+func TestDeduperConcurrent(t *testing.T) {
+	d := NewDeduper()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	added := 0
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				// every worker adds the same 100 urls, in a different order
+				if d.Add(fmt.Sprintf("https://example.com/%d", (i+w*13)%100)) {
+					mu.Lock()
+					added++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if added != 100 || d.Len() != 100 {
+		t.Errorf("%d urls reported new and Len() = %d, want 100", added, d.Len())
+	}
+}
@@ -0,0 +1,145 @@
+package learning
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// NextPageFunc returns the url of the page that follows result, or an empty
+// string when result is the last page
+type NextPageFunc func(result Result) (string, error)
+
+// Paginator follows a listing from page to page and collects every page
+type Paginator struct {
+	Scraper Scraper
+	// Next finds the next page, NextLink when nil
+	Next NextPageFunc
+	// MaxPages stops the listing after this many pages, zero means no limit
+	MaxPages int
+}
+
+// NewPaginator creates a Paginator that follows rel="next" links
+func NewPaginator(scraper Scraper, maxPages int) *Paginator {
+	return &Paginator{Scraper: scraper, MaxPages: maxPages}
+}
+
+// ScrapeAll fetches url and the pages after it, in order. It stops at the last
+// page, at MaxPages, when a page links back to one already fetched or at the
+// first error, which is returned along with the pages fetched so far.
+func (p *Paginator) ScrapeAll(ctx context.Context, url string) ([]Result, error) {
+	next := p.Next
+	if next == nil {
+		next = NextLink
+	}
+
+	var pages []Result
+	seen := map[string]bool{}
+	for url != "" && !seen[url] && (p.MaxPages <= 0 || len(pages) < p.MaxPages) {
+		seen[url] = true
+
+		result := scrapeOne(ctx, p.Scraper, url)
+		if result.Err != nil {
+			return pages, result.Err
+		}
+		pages = append(pages, result)
+
+		var err error
+		if url, err = next(result); err != nil {
+			return pages, fmt.Errorf("failed to find the page after %s: %w", result.URL, err)
+		}
+	}
+	return pages, nil
+}
+
+// NextLink finds the next page in the Link header of result, or else in a
+// <link> or <a> element with rel="next" in its html
+func NextLink(result Result) (string, error) {
+	base, err := url.Parse(result.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+	// relative links are relative to the page that was served after redirects
+	if n := len(result.Redirects); n > 0 {
+		if last, err := url.Parse(result.Redirects[n-1]); err == nil {
+			base = last
+		}
+	}
+
+	href := linkHeaderNext(result.Header.Values("Link"))
+	if href == "" {
+		doc, err := html.Parse(bytes.NewReader(result.Data))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse html: %w", err)
+		}
+		href = htmlNext(doc)
+	}
+	if href == "" {
+		return "", nil
+	}
+
+	ref, err := base.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse next link: %w", err)
+	}
+	ref.Fragment = ""
+	return ref.String(), nil
+}
+
+// linkHeaderNext returns the rel="next" target of Link headers, such as
+// <https://api.example.com/items?page=2>; rel="next"
+func linkHeaderNext(values []string) string {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "rel") && hasRel(strings.Trim(val, `"`), "next") {
+					return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// htmlNext returns the href of the first <link> or <a> with rel="next"
+func htmlNext(n *html.Node) string {
+	if n.Type == html.ElementNode && (n.Data == "link" || n.Data == "a") {
+		var href, rel string
+		for _, attr := range n.Attr {
+			switch attr.Key {
+			case "href":
+				href = attr.Val
+			case "rel":
+				rel = attr.Val
+			}
+		}
+		if href != "" && hasRel(rel, "next") {
+			return href
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if href := htmlNext(child); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+// hasRel reports whether the space separated rel list contains want
+func hasRel(rel, want string) bool {
+	for _, r := range strings.Fields(rel) {
+		if strings.EqualFold(r, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrSkipped is wrapped by the error of a url the filters decided not to fetch
+var ErrSkipped = errors.New("skipped")
+
+// SkippedURL is a url that won't be fetched and the reason why
+type SkippedURL struct {
+	URL    string
+	Reason string
+
+	// err is reported instead of the reason when set
+	err error
+}
+
+// Plan lists which urls a batch would fetch and which it would skip
+type Plan struct {
+	// Fetch holds the urls as they were given, without the duplicates
+	Fetch   []string
+	Skipped []SkippedURL
+}
+
+// robotsChecker is implemented by scrapers that follow robots.txt, like RobotsAwareScraper
+type robotsChecker interface {
+	Allowed(ctx context.Context, rawURL string) (bool, error)
+}
+
+// DryRun applies the scraper's filters to urls and reports what Scrape would do
+// without fetching any of them. When Scraper follows robots.txt, the urls it
+// disallows are skipped too, which takes one robots.txt request per host.
+func (c *ConcurrentScraper) DryRun(ctx context.Context, urls []string) Plan {
+	p := c.plan(urls)
+	robots, ok := c.Scraper.(robotsChecker)
+	if !ok {
+		return p
+	}
+
+	fetch := p.Fetch[:0:0]
+	for _, rawURL := range p.Fetch {
+		allowed, err := robots.Allowed(ctx, rawURL)
+		switch {
+		case err != nil:
+			p.Skipped = append(p.Skipped, SkippedURL{URL: rawURL, Reason: "robots.txt unavailable", err: err})
+		case !allowed:
+			p.Skipped = append(p.Skipped, SkippedURL{URL: rawURL, Reason: "robots",
+				err: fmt.Errorf("%w: %s", ErrRobotsDisallowed, rawURL)})
+		default:
+			fetch = append(fetch, rawURL)
+		}
+	}
+	p.Fetch = fetch
+	return p
+}
+
+// plan runs every url through the filters, in order, and drops the urls whose
+// normalized form was listed before. The urls to fetch keep their original form.
+func (c *ConcurrentScraper) plan(urls []string) Plan {
+	var p Plan
+	seen := map[string]bool{}
+	for _, rawURL := range urls {
+		if reason := c.skipReason(rawURL); reason != "" {
+			p.Skipped = append(p.Skipped, SkippedURL{URL: rawURL, Reason: reason})
+			continue
+		}
+		if err := c.Domains.Check(rawURL); err != nil {
+			p.Skipped = append(p.Skipped, SkippedURL{URL: rawURL, Reason: "filtered", err: err})
+			continue
+		}
+		key := normalizeOrRaw(rawURL)
+		if seen[key] {
+			p.Skipped = append(p.Skipped, SkippedURL{URL: rawURL, Reason: "duplicate"})
+			continue
+		}
+		if c.Deduper != nil && c.Deduper.Seen(rawURL) {
+			p.Skipped = append(p.Skipped, SkippedURL{URL: rawURL, Reason: "already scraped"})
+			continue
+		}
+		seen[key] = true
+		p.Fetch = append(p.Fetch, rawURL)
+	}
+	return p
+}
+
+// skipReason returns why rawURL is filtered out, or an empty string if it should be fetched
+func (c *ConcurrentScraper) skipReason(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid url"
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Sprintf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "missing host"
+	}
+	return ""
+}
+
+// Err returns the error reported in the Result of a skipped url
+func (s SkippedURL) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return fmt.Errorf("%w: %s", ErrSkipped, s.Reason)
+}
@@ -0,0 +1,68 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	c := NewConcurrentScraper(nil, 1)
+	plan := c.DryRun(context.Background(), []string{
+		"https://example.com",
+		"https://example.com/",
+		"ftp://example.com/file",
+		"https://example.com/?flag",
+		"https://example.com/#top",
+	})
+
+	// urls are fetched as given, only their normalized form is compared
+	if want := []string{"https://example.com", "https://example.com/?flag"}; !slices.Equal(plan.Fetch, want) {
+		t.Errorf("Fetch = %q, want %q", plan.Fetch, want)
+	}
+	var reasons []string
+	for _, s := range plan.Skipped {
+		reasons = append(reasons, s.Reason)
+	}
+	if want := []string{"duplicate", `unsupported scheme "ftp"`, "duplicate"}; !slices.Equal(reasons, want) {
+		t.Errorf("skip reasons = %q, want %q", reasons, want)
+	}
+}
+
+// requestLog records every url it is asked for
+type requestLog struct {
+	robots string
+	urls   []string
+}
+
+func (l *requestLog) Scrape(ctx context.Context, url string) ([]byte, error) {
+	l.urls = append(l.urls, url)
+	if strings.HasSuffix(url, "/robots.txt") {
+		return []byte(l.robots), nil
+	}
+	return []byte("page"), nil
+}
+
+func TestDryRunRobots(t *testing.T) {
+	log := &requestLog{robots: "User-agent: *\nDisallow: /private"}
+	c := NewConcurrentScraper(NewRobotsAwareScraper(log, "bot"), 1)
+	plan := c.DryRun(context.Background(), []string{
+		"https://example.com/public",
+		"https://example.com/private/page",
+		"https://example.com/other",
+	})
+
+	if want := []string{"https://example.com/public", "https://example.com/other"}; !slices.Equal(plan.Fetch, want) {
+		t.Errorf("Fetch = %q, want %q", plan.Fetch, want)
+	}
+	if len(plan.Skipped) != 1 || plan.Skipped[0].URL != "https://example.com/private/page" ||
+		!errors.Is(plan.Skipped[0].Err(), ErrRobotsDisallowed) {
+		t.Errorf("Skipped = %+v, want the private page disallowed by robots.txt", plan.Skipped)
+	}
+	// robots.txt is all that is fetched
+	if want := []string{"https://example.com/robots.txt"}; !slices.Equal(log.urls, want) {
+		t.Errorf("requested %q, want %q", log.urls, want)
+	}
+}
@@ -0,0 +1,90 @@
+package learning
+
+import (
+	"container/heap"
+	"context"
+)
+
+// PriorityURL is a url with the priority it is scraped with, higher goes first
+type PriorityURL struct {
+	URL      string
+	Priority int
+}
+
+// WithPriority gives every url the priority priority returns for it, including
+// the urls found by Discover. Urls of equal priority keep their order.
+func WithPriority(priority func(url string) int) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Priority = priority
+	}
+}
+
+// ScrapePriority works like ScrapeWithError but dispatches the urls with the
+// highest priority first instead of in the order given
+func (c *ConcurrentScraper) ScrapePriority(ctx context.Context, urls []PriorityURL) ([]Result, error) {
+	b := c.newBatch(ctx)
+	defer b.cancel(nil)
+
+	err := c.run(b, urls)
+	return b.results, err
+}
+
+// prioritized tags urls with the priorities from c.Priority
+func (c *ConcurrentScraper) prioritized(urls []string) []PriorityURL {
+	tagged := make([]PriorityURL, len(urls))
+	for i, url := range urls {
+		tagged[i] = PriorityURL{URL: url, Priority: c.priority(url)}
+	}
+	return tagged
+}
+
+func (c *ConcurrentScraper) priority(url string) int {
+	if c.Priority == nil {
+		return 0
+	}
+	return c.Priority(url)
+}
+
+// urlQueue is a heap of the urls waiting for a worker, by priority and then
+// by the order they were queued in
+type urlQueue struct {
+	items []queuedURL
+	seq   uint64
+}
+
+type queuedURL struct {
+	PriorityURL
+	seq uint64
+}
+
+func (q *urlQueue) push(url string, priority int) {
+	heap.Push(q, queuedURL{PriorityURL: PriorityURL{URL: url, Priority: priority}, seq: q.seq})
+	q.seq++
+}
+
+func (q *urlQueue) pop() (string, bool) {
+	if q.Len() == 0 {
+		return "", false
+	}
+	return heap.Pop(q).(queuedURL).URL, true
+}
+
+func (q *urlQueue) Len() int { return len(q.items) }
+
+func (q *urlQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.seq < b.seq
+}
+
+func (q *urlQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *urlQueue) Push(x any) { q.items = append(q.items, x.(queuedURL)) }
+
+func (q *urlQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
@@ -0,0 +1,31 @@
+package learning
+
+// ProgressFunc is told how many urls of a batch are done, how many the batch
+// holds so far and which result finished last. total grows when Discover adds urls.
+// Calls never overlap, so a ProgressFunc may keep its own counters without locking,
+// but it should return quickly as it holds up the worker that finished last.
+type ProgressFunc func(done, total int, last Result)
+
+// WithProgress reports the progress of every batch to progress
+func WithProgress(progress ProgressFunc) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Progress = progress
+	}
+}
+
+// reportProgress counts last as done and tells the ProgressFunc of the batch
+func (b *batch) reportProgress(last Result) {
+	if b.progress == nil {
+		return
+	}
+
+	b.progressMu.Lock()
+	defer b.progressMu.Unlock()
+
+	b.mu.Lock()
+	total := b.total
+	b.mu.Unlock()
+
+	b.finished++
+	b.progress(b.finished, total, last)
+}
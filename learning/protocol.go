@@ -0,0 +1,107 @@
+package learning
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// ErrProtocol is returned when a response wasn't served over the forced protocol
+var ErrProtocol = errors.New("unexpected protocol")
+
+// HTTPVersion picks the protocol SimpleScraper speaks
+type HTTPVersion int
+
+const (
+	// HTTPAuto uses HTTP/2 when the server offers it over TLS and HTTP/1.1 otherwise
+	HTTPAuto HTTPVersion = iota
+	// HTTP1 never uses HTTP/2
+	HTTP1
+	// HTTP2 fails every response that wasn't served over HTTP/2 with ErrProtocol
+	HTTP2
+	// HTTP3 sends https requests over QUIC and falls back to the regular
+	// transport for hosts where that fails. QUIC requests bypass WithDialTimeout,
+	// WithResolver and the proxy options.
+	HTTP3
+)
+
+// WithHTTPVersion makes the scraper speak the given protocol
+func WithHTTPVersion(v HTTPVersion) Option {
+	return func(s *SimpleScraper) {
+		s.httpVersion = v
+	}
+}
+
+// configureProtocol sets up the client for s.httpVersion, it runs after the options
+func (s *SimpleScraper) configureProtocol() {
+	switch s.httpVersion {
+	case HTTP1:
+		s.transport.ForceAttemptHTTP2 = false
+		// a non-nil empty map turns the built-in HTTP/2 support off
+		s.transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case HTTP2:
+		s.transport.ForceAttemptHTTP2 = true
+	case HTTP3:
+		h3 := &http3.Transport{}
+		if s.transport.TLSClientConfig != nil {
+			h3.TLSClientConfig = s.transport.TLSClientConfig.Clone()
+		}
+		s.Client.Transport = &h3Fallback{h3: h3, fallback: s.Client.Transport, broken: map[string]bool{}}
+	}
+}
+
+// checkProtocol enforces HTTP2 on a response
+func (s *SimpleScraper) checkProtocol(resp *http.Response, url string) error {
+	if s.httpVersion == HTTP2 && resp.ProtoMajor != 2 {
+		return fmt.Errorf("%w: %s was served over %s", ErrProtocol, url, resp.Proto)
+	}
+	return nil
+}
+
+// h3Fallback tries HTTP/3 first and remembers the hosts where it didn't work,
+// so only the first request to such a host waits for the QUIC handshake to fail
+type h3Fallback struct {
+	h3       *http3.Transport
+	fallback http.RoundTripper
+
+	mu     sync.Mutex
+	broken map[string]bool
+}
+
+func (t *h3Fallback) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" || t.isBroken(req.URL.Host) {
+		return t.fallback.RoundTrip(req)
+	}
+
+	resp, err := t.h3.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+	// a request body may be partly sent already, so it can't be replayed
+	if req.Context().Err() != nil || (req.Body != nil && req.Body != http.NoBody) {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.broken[req.URL.Host] = true
+	t.mu.Unlock()
+	return t.fallback.RoundTrip(req)
+}
+
+func (t *h3Fallback) isBroken(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.broken[host]
+}
+
+// CloseIdleConnections closes the idle connections of both transports
+func (t *h3Fallback) CloseIdleConnections() {
+	t.h3.CloseIdleConnections()
+	if c, ok := t.fallback.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,115 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ProxyStrategy decides which proxy of a ProxyPool the next request uses
+type ProxyStrategy int
+
+const (
+	// ProxyRoundRobin uses the proxies one after the other
+	ProxyRoundRobin ProxyStrategy = iota
+	// ProxyRandom picks a random proxy for every request
+	ProxyRandom
+	// ProxyFailureAware prefers the proxies with the fewest failures in a row
+	ProxyFailureAware
+)
+
+// ProxyPool holds the HTTP/HTTPS proxies a SimpleScraper rotates through
+type ProxyPool struct {
+	strategy ProxyStrategy
+	rand     *lockedRand
+
+	mu       sync.Mutex
+	proxies  []*url.URL
+	failures []int
+	next     int
+}
+
+// NewProxyPool creates a ProxyPool from proxy urls such as http://host:3128
+func NewProxyPool(proxies []string, strategy ProxyStrategy) (*ProxyPool, error) {
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("proxy pool: %w", ErrNoBackends)
+	}
+
+	p := &ProxyPool{
+		strategy: strategy,
+		rand:     newLockedRand(rand.NewSource(time.Now().UnixNano())),
+		failures: make([]int, len(proxies)),
+	}
+	for _, raw := range proxies {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %s: %w", raw, err)
+		}
+		p.proxies = append(p.proxies, u)
+	}
+	return p, nil
+}
+
+// WithProxyPool sends every request through a proxy from pool
+func WithProxyPool(pool *ProxyPool) Option {
+	return func(s *SimpleScraper) {
+		s.proxies = pool
+		s.transport.Proxy = proxyFromContext
+	}
+}
+
+// pick returns the index of the proxy for the next request
+func (p *ProxyPool) pick() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.strategy {
+	case ProxyRandom:
+		return int(p.rand.Float64() * float64(len(p.proxies)))
+	case ProxyFailureAware:
+		best := -1
+		for i := range p.proxies {
+			j := (p.next + i) % len(p.proxies)
+			if best < 0 || p.failures[j] < p.failures[best] {
+				best = j
+			}
+		}
+		p.next = (best + 1) % len(p.proxies)
+		return best
+	default:
+		i := p.next
+		p.next = (p.next + 1) % len(p.proxies)
+		return i
+	}
+}
+
+// report records whether a request through proxy i reached the server
+func (p *ProxyPool) report(i int, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ok {
+		p.failures[i] = 0
+	} else {
+		p.failures[i]++
+	}
+}
+
+type proxyKey struct{}
+
+// withProxy picks a proxy for req and stores it in the request context where
+// the transport's Proxy func finds it, the returned func reports the outcome
+func (p *ProxyPool) withProxy(req *http.Request) (*http.Request, func(ok bool)) {
+	i := p.pick()
+	ctx := context.WithValue(req.Context(), proxyKey{}, p.proxies[i])
+	return req.WithContext(ctx), func(ok bool) { p.report(i, ok) }
+}
+
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	u, _ := req.Context().Value(proxyKey{}).(*url.URL)
+	return u, nil
+}
@@ -0,0 +1,136 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit holds the rate limit a server reported in its response headers,
+// Remaining is -1 when the server only sent a reset time
+type RateLimit struct {
+	Remaining int
+	Reset     time.Time
+}
+
+// ParseRateLimit reads the X-RateLimit-* (or RateLimit-*) headers, it returns nil
+// when the response has none
+func ParseRateLimit(header http.Header) *RateLimit {
+	return parseRateLimit(header, time.Now())
+}
+
+func parseRateLimit(header http.Header, now time.Time) *RateLimit {
+	remaining := firstHeader(header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	reset := firstHeader(header, "X-RateLimit-Reset", "RateLimit-Reset")
+	if remaining == "" && reset == "" {
+		return nil
+	}
+
+	rl := &RateLimit{Remaining: -1}
+	if n, err := strconv.Atoi(remaining); err == nil {
+		rl.Remaining = n
+	}
+	rl.Reset = parseReset(reset, now)
+	return rl
+}
+
+// parseReset understands unix timestamps, seconds until the reset and http dates
+func parseReset(value string, now time.Time) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// anything this large is a unix timestamp rather than a delay
+		if n > 1_000_000_000 {
+			return time.Unix(n, 0)
+		}
+		return now.Add(time.Duration(n) * time.Second)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+func firstHeader(header http.Header, keys ...string) string {
+	for _, key := range keys {
+		if v := header.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// WithAdaptiveRateLimit slows requests to a host down once its remaining rate limit
+// drops below threshold, spreading the remaining requests until the reset time.
+// A single delay is never longer than maxDelay.
+func WithAdaptiveRateLimit(threshold int, maxDelay time.Duration) Option {
+	return func(s *SimpleScraper) {
+		s.pacer = &ratePacer{
+			threshold: threshold,
+			maxDelay:  maxDelay,
+			limits:    map[string]RateLimit{},
+		}
+	}
+}
+
+// ratePacer remembers the last rate limit seen per host
+type ratePacer struct {
+	threshold int
+	maxDelay  time.Duration
+
+	mu     sync.Mutex
+	limits map[string]RateLimit
+}
+
+// Update stores the rate limit reported by host
+func (p *ratePacer) Update(host string, rl *RateLimit) {
+	if rl == nil || rl.Remaining < 0 {
+		return
+	}
+	p.mu.Lock()
+	p.limits[host] = *rl
+	p.mu.Unlock()
+}
+
+// Reset forgets the rate limits seen so far
+func (p *ratePacer) Reset() {
+	p.mu.Lock()
+	p.limits = map[string]RateLimit{}
+	p.mu.Unlock()
+}
+
+// Wait sleeps as long as needed before the next request to host, or until ctx is done
+func (p *ratePacer) Wait(ctx context.Context, clock Clock, host string) error {
+	delay := p.delay(host, clock.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *ratePacer) delay(host string, now time.Time) time.Duration {
+	p.mu.Lock()
+	rl, ok := p.limits[host]
+	p.mu.Unlock()
+	if !ok || rl.Remaining >= p.threshold || !rl.Reset.After(now) {
+		return 0
+	}
+
+	delay := rl.Reset.Sub(now)
+	if rl.Remaining > 0 {
+		delay /= time.Duration(rl.Remaining)
+	}
+	if p.maxDelay > 0 && delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return delay
+}
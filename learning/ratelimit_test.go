@@ -0,0 +1,124 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   *RateLimit
+	}{
+		{"none", http.Header{}, nil},
+		{"delay", http.Header{"X-Ratelimit-Remaining": {"5"}, "X-Ratelimit-Reset": {"30"}},
+			&RateLimit{Remaining: 5, Reset: now.Add(30 * time.Second)}},
+		{"unix time", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1714565000"}},
+			&RateLimit{Remaining: 0, Reset: time.Unix(1714565000, 0)}},
+		{"http date", http.Header{"Ratelimit-Reset": {"Wed, 01 May 2024 12:01:00 GMT"}},
+			&RateLimit{Remaining: -1, Reset: now.Add(time.Minute)}},
+		{"draft headers", http.Header{"Ratelimit-Remaining": {"7"}}, &RateLimit{Remaining: 7}},
+		{"garbage", http.Header{"X-Ratelimit-Remaining": {"many"}, "X-Ratelimit-Reset": {"later"}},
+			&RateLimit{Remaining: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRateLimit(tt.header, now)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("parseRateLimit() = %v, want %v", got, tt.want)
+			}
+			if got != nil && (got.Remaining != tt.want.Remaining || !got.Reset.Equal(tt.want.Reset)) {
+				t.Errorf("parseRateLimit() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestResultRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", "60")
+	}))
+	defer srv.Close()
+
+	result := NewSimpleScraper(5*time.Second).ScrapeResult(context.Background(), srv.URL)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if result.RateLimit == nil || result.RateLimit.Remaining != 42 || result.RateLimit.Reset.IsZero() {
+		t.Errorf("Result.RateLimit = %+v, want 42 remaining with a reset time", result.RateLimit)
+	}
+}
+
+func TestRatePacerDelay(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		limit    *RateLimit
+		maxDelay time.Duration
+		want     time.Duration
+	}{
+		{"unknown host", nil, 0, 0},
+		{"above threshold", &RateLimit{Remaining: 10, Reset: now.Add(time.Minute)}, 0, 0},
+		{"spread over the rest", &RateLimit{Remaining: 4, Reset: now.Add(time.Minute)}, 0, 15 * time.Second},
+		{"none left", &RateLimit{Remaining: 0, Reset: now.Add(time.Minute)}, 0, time.Minute},
+		{"capped", &RateLimit{Remaining: 0, Reset: now.Add(time.Minute)}, 5 * time.Second, 5 * time.Second},
+		{"reset passed", &RateLimit{Remaining: 0, Reset: now.Add(-time.Second)}, 0, 0},
+		{"no reset", &RateLimit{Remaining: 0}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ratePacer{threshold: 5, maxDelay: tt.maxDelay, limits: map[string]RateLimit{}}
+			p.Update("example.com", tt.limit)
+			if got := p.delay("example.com", now); got != tt.want {
+				t.Errorf("delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveRateLimitWaits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "1")
+		w.Header().Set("X-RateLimit-Reset", "10")
+	}))
+	defer srv.Close()
+
+	clock := NewMockClock(time.Now())
+	s := NewSimpleScraper(5*time.Second, WithClock(clock), WithAdaptiveRateLimit(5, time.Minute))
+	if result := s.ScrapeResult(context.Background(), srv.URL); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	// one request left for 10s, the next one waits for the whole 10s
+	done := make(chan Result, 1)
+	go func() { done <- s.ScrapeResult(context.Background(), srv.URL) }()
+	waitFor(t, func() bool {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return len(clock.waiters) == 1
+	})
+	clock.Advance(9 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("the request went out before the rate limit reset")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	if result := <-done; result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	// Reset forgets the limit, so the next request goes out right away
+	s.Reset()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if result := s.ScrapeResult(ctx, srv.URL); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+}
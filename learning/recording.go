@@ -0,0 +1,169 @@
+package learning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// ErrNotRecorded is returned in ModeReplayOnly for urls missing from the cassette
+var ErrNotRecorded = errors.New("url not recorded")
+
+// RecordMode tells a RecordingScraper when to use the network
+type RecordMode int
+
+const (
+	// ModeRecord fetches every url and stores the response
+	ModeRecord RecordMode = iota
+	// ModeReplay serves recorded urls and fetches and records the rest
+	ModeReplay
+	// ModeReplayOnly serves recorded urls and fails on the rest
+	ModeReplayOnly
+)
+
+// cassetteEntry is a single recorded response
+type cassetteEntry struct {
+	Data []byte `json:"data,omitempty"`
+	Err  string `json:"err,omitempty"`
+	// Class and Status keep errors.Is working on a replayed error
+	Class  string `json:"class,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+// RecordingScraper wraps a Scraper and records its responses to a cassette file
+// so later runs can replay them without hitting the network. Failures that
+// could go away on the next try, like timeouts and 5xx responses, aren't recorded.
+type RecordingScraper struct {
+	Scraper Scraper
+	Path    string
+	Mode    RecordMode
+
+	mu       sync.Mutex
+	cassette map[string]cassetteEntry
+}
+
+// NewRecordingScraper creates a RecordingScraper and loads the cassette at path if it exists
+func NewRecordingScraper(scraper Scraper, path string, mode RecordMode) (*RecordingScraper, error) {
+	r := &RecordingScraper{
+		Scraper:  scraper,
+		Path:     path,
+		Mode:     mode,
+		cassette: map[string]cassetteEntry{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette: %w", err)
+	}
+
+	return r, nil
+}
+
+// Name identifies the RecordingScraper and the scraper it wraps
+func (r *RecordingScraper) Name() string {
+	return "recording(" + ScraperName(r.Scraper) + ")"
+}
+
+// Scrape replays or records the response for url depending on the mode
+func (r *RecordingScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	if r.Mode != ModeRecord {
+		r.mu.Lock()
+		entry, ok := r.cassette[url]
+		r.mu.Unlock()
+		if ok {
+			return entry.replay()
+		}
+		if r.Mode == ModeReplayOnly {
+			return nil, fmt.Errorf("%w: %s", ErrNotRecorded, url)
+		}
+	}
+
+	data, err := r.Scraper.Scrape(ctx, url)
+	if err != nil && !recordable(err) {
+		return data, err
+	}
+	entry := cassetteEntry{Data: data}
+	if err != nil {
+		entry.Err = err.Error()
+		entry.Class = ErrorClass(err)
+		var bad ErrBadStatus
+		if errors.As(err, &bad) {
+			entry.Status = bad.Code
+		}
+	}
+
+	r.mu.Lock()
+	r.cassette[url] = entry
+	r.mu.Unlock()
+
+	return data, err
+}
+
+// Save writes the cassette to Path
+func (r *RecordingScraper) Save() error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.WriteFile(r.Path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Close saves the cassette and closes the wrapped scraper
+func (r *RecordingScraper) Close() error {
+	return errors.Join(r.Save(), closeScraper(r.Scraper))
+}
+
+func (e cassetteEntry) replay() ([]byte, error) {
+	if e.Err == "" {
+		return e.Data, nil
+	}
+	err := &replayedError{msg: e.Err}
+	switch {
+	case e.Status != 0:
+		err.class = ErrBadStatus{Code: e.Status}
+	case e.Class != "":
+		err.class = classErrors[e.Class]
+	}
+	return e.Data, err
+}
+
+// recordable reports whether err will come back the same on the next try, so
+// replaying it is fine
+func recordable(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, ErrTimeout) && !retryable(err)
+}
+
+// classErrors maps the classes of ErrorClass back to their errors
+var classErrors = map[string]error{
+	"not_found": ErrNotFound,
+	"skipped":   ErrSkipped,
+	"filtered":  &FilteredError{},
+	"robots":    ErrRobotsDisallowed,
+	"too_large": ErrTooLarge,
+}
+
+// replayedError has the text of the recorded error and matches its class
+type replayedError struct {
+	msg   string
+	class error
+}
+
+func (e *replayedError) Error() string { return e.msg }
+func (e *replayedError) Unwrap() error { return e.class }
@@ -0,0 +1,112 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// failingScraper fails every url with err
+type failingScraper struct {
+	err   error
+	calls int
+}
+
+func (f *failingScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func TestRecordingScraperErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantRecord bool
+		wantIs     error
+	}{
+		{"not found", ErrBadStatus{Code: 404}, true, ErrBadStatus{Code: 404}},
+		{"skipped", fmt.Errorf("%w: unsupported scheme", ErrSkipped), true, ErrSkipped},
+		{"robots", fmt.Errorf("%w: u", ErrRobotsDisallowed), true, ErrRobotsDisallowed},
+		{"server error", ErrBadStatus{Code: 503}, false, nil},
+		{"timeout", markTimeout(context.Background(), context.DeadlineExceeded), false, nil},
+		{"canceled", context.Canceled, false, nil},
+		{"network", errors.New("connection reset"), false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassette.json")
+			backend := &failingScraper{err: tt.err}
+			r, err := NewRecordingScraper(backend, path, ModeReplay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Scrape(context.Background(), "https://example.com")
+			if err := r.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			replay, err := NewRecordingScraper(backend, path, ModeReplayOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = replay.Scrape(context.Background(), "https://example.com")
+			if recorded := !errors.Is(err, ErrNotRecorded); recorded != tt.wantRecord {
+				t.Fatalf("recorded = %v, want %v", recorded, tt.wantRecord)
+			}
+			if tt.wantIs != nil {
+				if !errors.Is(err, tt.wantIs) || err.Error() != tt.err.Error() {
+					t.Errorf("replayed %v, want %v matching %v", err, tt.err, tt.wantIs)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordingScraperReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	stub := NewStubScraper(map[string][]byte{"https://example.com/": []byte("page")})
+	r, err := NewRecordingScraper(stub, path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Scrape(context.Background(), "https://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		mode      RecordMode
+		url       string
+		want      string
+		wantErr   error
+		wantCalls int
+	}{
+		{"replay only", ModeReplayOnly, "https://example.com/", "page", nil, 0},
+		{"replay", ModeReplay, "https://example.com/", "page", nil, 0},
+		{"replay only missing", ModeReplayOnly, "https://example.com/new", "", ErrNotRecorded, 0},
+		{"replay missing", ModeReplay, "https://example.com/new", "", ErrBadStatus{Code: 404}, 1},
+		{"record", ModeRecord, "https://example.com/", "", ErrBadStatus{Code: 404}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the network is gone, only the cassette can answer
+			backend := &failingScraper{err: ErrBadStatus{Code: 404}}
+			replay, err := NewRecordingScraper(backend, path, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := replay.Scrape(context.Background(), tt.url)
+			if string(data) != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Scrape() = %q, %v, want %q, %v", data, err, tt.want, tt.wantErr)
+			}
+			if backend.calls != tt.wantCalls {
+				t.Errorf("the backend was called %d times, want %d", backend.calls, tt.wantCalls)
+			}
+		})
+	}
+}
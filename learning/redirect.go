@@ -0,0 +1,70 @@
+package learning
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrRedirectRejected is wrapped by the error of a request whose redirect broke the redirect policy
+var ErrRedirectRejected = errors.New("redirect rejected")
+
+// redirectPolicy decides which redirects SimpleScraper follows
+type redirectPolicy struct {
+	disabled bool
+	// maxHops is the number of redirects followed, zero keeps the net/http limit of 10
+	maxHops  int
+	sameHost bool
+}
+
+// WithoutRedirects returns redirect responses as they are, they then fail with a bad status code
+func WithoutRedirects() Option {
+	return func(s *SimpleScraper) {
+		s.redirects.disabled = true
+	}
+}
+
+// WithMaxRedirects follows at most n redirects per request
+func WithMaxRedirects(n int) Option {
+	return func(s *SimpleScraper) {
+		s.redirects.maxHops = n
+	}
+}
+
+// WithSameHostRedirects only follows redirects that stay on the host of the original url
+func WithSameHostRedirects() Option {
+	return func(s *SimpleScraper) {
+		s.redirects.sameHost = true
+	}
+}
+
+// checkRedirect implements http.Client.CheckRedirect for the policy
+func (p *redirectPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	maxHops := p.maxHops
+	if maxHops <= 0 {
+		maxHops = 10
+	}
+
+	switch {
+	case p.disabled:
+		return http.ErrUseLastResponse
+	case len(via) > maxHops:
+		return fmt.Errorf("%w: stopped after %d redirects", ErrRedirectRejected, maxHops)
+	case p.sameHost && req.URL.Hostname() != via[0].URL.Hostname():
+		return fmt.Errorf("%w: %s leaves host %s", ErrRedirectRejected, req.URL.Redacted(), via[0].URL.Hostname())
+	}
+	return nil
+}
+
+// redirectChain lists the urls resp was redirected to, in order, ending with
+// the url that served it. It is nil when there was no redirect.
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append(chain, req.URL.Redacted())
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
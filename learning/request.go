@@ -0,0 +1,122 @@
+package learning
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// ScrapeRequest describes a single request, its headers replace the scraper's
+// headers with the same name
+type ScrapeRequest struct {
+	// Method is the http method, GET when empty
+	Method string
+	URL    string
+	Header http.Header
+	// Body is sent with the request, it is a byte slice so retries can send it again
+	Body []byte
+	// GzipBody compresses Body and sends it with Content-Encoding: gzip, for
+	// servers that accept compressed uploads
+	GzipBody bool
+	Cookies  []*http.Cookie
+	// Timeout replaces the client's timeout for this request, see WithRequestTimeout
+	Timeout time.Duration
+}
+
+// JSONRequest creates a request that sends v encoded as JSON
+func JSONRequest(method, url string, v any) (ScrapeRequest, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return ScrapeRequest{}, fmt.Errorf("failed to encode request body: %w", err)
+	}
+	return ScrapeRequest{
+		Method: method,
+		URL:    url,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   body,
+	}, nil
+}
+
+// FormRequest creates a POST request that submits values like an html form
+func FormRequest(url string, values neturl.Values) ScrapeRequest {
+	return ScrapeRequest{
+		Method: http.MethodPost,
+		URL:    url,
+		Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+		Body:   []byte(values.Encode()),
+	}
+}
+
+func (sr ScrapeRequest) method() string {
+	if sr.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(sr.Method)
+}
+
+// safe reports whether the request only reads, those are the ones whose
+// validators are stored and sent
+func (sr ScrapeRequest) safe() bool {
+	m := sr.method()
+	return m == http.MethodGet || m == http.MethodHead
+}
+
+// idempotent reports whether sending the request twice does no harm, the others
+// are never retried
+func (sr ScrapeRequest) idempotent() bool {
+	switch sr.method() {
+	case http.MethodPost, http.MethodPatch, http.MethodConnect:
+		return false
+	}
+	return true
+}
+
+// newRequest creates the http request for sr without the scraper's headers
+func (sr ScrapeRequest) newRequest(ctx context.Context) (*http.Request, error) {
+	if sr.Body == nil {
+		return http.NewRequestWithContext(ctx, sr.method(), sr.URL, nil)
+	}
+	body := sr.Body
+	if sr.GzipBody {
+		var err error
+		if body, err = gzipBody(body); err != nil {
+			return nil, err
+		}
+	}
+	// a *bytes.Reader lets the client replay the body on redirects
+	req, err := http.NewRequestWithContext(ctx, sr.method(), sr.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if sr.GzipBody {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, nil
+}
+
+// gzipBody compresses a request body
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// apply adds the request's own headers and cookies to req
+func (sr ScrapeRequest) apply(req *http.Request) {
+	setHeaders(req.Header, sr.Header)
+	for _, cookie := range sr.Cookies {
+		req.AddCookie(cookie)
+	}
+}
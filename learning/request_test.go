@@ -0,0 +1,60 @@
+package learning
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGzipRequestBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, r.Header.Get("Content-Encoding")+":"+string(data))
+	}))
+	defer srv.Close()
+
+	payload := strings.Repeat(`{"name":"value"}`, 100)
+	tests := []struct {
+		name string
+		gzip bool
+		want string
+	}{
+		{"plain", false, ":" + payload},
+		{"gzip", true, "gzip:" + payload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr, err := JSONRequest(http.MethodPost, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sr.Body = []byte(payload)
+			sr.GzipBody = tt.gzip
+			result := NewSimpleScraper(5*time.Second).ScrapeWith(context.Background(), sr)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if string(result.Data) != tt.want {
+				t.Errorf("the server got %.40q, want %.40q", result.Data, tt.want)
+			}
+		})
+	}
+}
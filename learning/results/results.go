@@ -0,0 +1,153 @@
+package results
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"test/learning"
+)
+
+// Record is the flat form a Result is exported in
+type Record struct {
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Started    time.Time `json:"started"`
+	DurationMS int64     `json:"duration_ms"`
+	Bytes      int       `json:"bytes"`
+	Body       string    `json:"body,omitempty"`
+	// Truncated is set when Body holds only the start of the text
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Option configures how results are exported
+type Option func(*options)
+
+type options struct {
+	text    bool
+	maxText int
+	noBody  bool
+}
+
+// WithTextBody exports bodies as text cut to maxLen bytes instead of base64,
+// zero keeps the whole body
+func WithTextBody(maxLen int) Option {
+	return func(o *options) {
+		o.text = true
+		o.maxText = maxLen
+	}
+}
+
+// WithoutBody leaves the bodies out
+func WithoutBody() Option {
+	return func(o *options) {
+		o.noBody = true
+	}
+}
+
+// NewRecord flattens result, the body is base64 encoded unless opts say otherwise
+func NewRecord(result learning.Result, opts ...Option) Record {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r := Record{
+		URL:        result.URL,
+		StatusCode: result.StatusCode,
+		Started:    result.Started,
+		DurationMS: result.Duration.Milliseconds(),
+		Bytes:      len(result.Data),
+	}
+	if result.Err != nil {
+		r.Error = result.Err.Error()
+	}
+
+	switch {
+	case o.noBody:
+	case o.text:
+		r.Body, r.Truncated = truncate(result.Data, o.maxText)
+	default:
+		r.Body = base64.StdEncoding.EncodeToString(result.Data)
+	}
+	return r
+}
+
+// WriteJSON writes results as a single JSON array
+func WriteJSON(w io.Writer, results []learning.Result, opts ...Option) error {
+	records := make([]Record, 0, len(results))
+	for _, result := range results {
+		records = append(records, NewRecord(result, opts...))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(records); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
+	}
+	return nil
+}
+
+// WriteNDJSON writes one JSON object per line, so large runs can be read back
+// one result at a time
+func WriteNDJSON(w io.Writer, results []learning.Result, opts ...Option) error {
+	enc := json.NewEncoder(w)
+	for _, result := range results {
+		if err := enc.Encode(NewRecord(result, opts...)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", result.URL, err)
+		}
+	}
+	return nil
+}
+
+// csvHeader names the columns written by WriteCSV
+var csvHeader = []string{"url", "status_code", "error", "started", "duration_ms", "bytes", "body", "truncated"}
+
+// WriteCSV writes results as CSV with a header row
+func WriteCSV(w io.Writer, results []learning.Result, opts ...Option) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, result := range results {
+		r := NewRecord(result, opts...)
+		row := []string{
+			r.URL,
+			strconv.Itoa(r.StatusCode),
+			r.Error,
+			r.Started.Format(time.RFC3339Nano),
+			strconv.FormatInt(r.DurationMS, 10),
+			strconv.Itoa(r.Bytes),
+			r.Body,
+			strconv.FormatBool(r.Truncated),
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write %s: %w", result.URL, err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
+
+// truncate returns data as text of at most maxLen bytes without splitting a rune
+func truncate(data []byte, maxLen int) (string, bool) {
+	if maxLen <= 0 || len(data) <= maxLen {
+		return string(data), false
+	}
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return string(data[:cut]), true
+}
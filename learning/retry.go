@@ -0,0 +1,165 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures how SimpleScraper retries transient failures, which
+// are network errors, truncated or empty bodies, 429 and 5xx responses. A Retry-After header on a
+// 429 or 503 response makes the retry wait at least that long.
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	// Factor multiplies the delay after every attempt
+	Factor float64
+	// Jitter randomizes each delay by up to this fraction, 0.2 means +/-20%
+	Jitter float64
+	// MaxRetryAfter is the longest Retry-After that is waited for, a server
+	// asking for more fails the url right away. Zero means no limit.
+	MaxRetryAfter time.Duration
+	// PauseHost holds back every request to a host that sent Retry-After, not
+	// only the retry, until the wait is over
+	PauseHost bool
+}
+
+// WithRetry retries transient failures following policy
+func WithRetry(policy RetryPolicy) Option {
+	return func(s *SimpleScraper) {
+		s.retry = &policy
+		s.pauses = nil
+		if policy.PauseHost {
+			s.pauses = &hostPauses{until: map[string]time.Time{}}
+		}
+	}
+}
+
+// WithRandSource draws the retry jitter from src instead of a source seeded
+// from the current time, a fixed seed makes the delays repeatable
+func WithRandSource(src rand.Source) Option {
+	return func(s *SimpleScraper) {
+		s.rand = newLockedRand(src)
+	}
+}
+
+// retryable tells whether a failed attempt is worth another try. Errors that
+// would come back the same, like a 404 or an invalid url, are not; unknown
+// errors, like dropped connections and truncated bodies, are.
+func retryable(err error) bool {
+	var hookErr *HookError
+	var bad ErrBadStatus
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrEmptyBody):
+		return true
+	case errors.As(err, &bad):
+		return bad.Code == http.StatusTooManyRequests || bad.Code >= 500
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrRedirectRejected), errors.As(err, &hookErr),
+		errors.Is(err, ErrSkipped), errors.Is(err, ErrRobotsDisallowed), errors.Is(err, ErrTooLarge),
+		errors.Is(err, ErrNotModified), errors.Is(err, ErrProtocol), errors.Is(err, ErrNotFound):
+		return false
+	}
+	return true
+}
+
+// retryDelay returns how long to wait before the given retry of result, ok is
+// false when the server asked for a longer wait than the policy allows
+func (p *RetryPolicy) retryDelay(retry int, result Result, rnd *lockedRand) (d time.Duration, ok bool) {
+	d = p.delay(retry, rnd)
+	if result.RetryAfter > 0 {
+		if p.MaxRetryAfter > 0 && result.RetryAfter > p.MaxRetryAfter {
+			return 0, false
+		}
+		d = max(d, result.RetryAfter)
+	}
+	return d, true
+}
+
+// parseRetryAfter reads a Retry-After header, which holds either seconds or an
+// http date. It returns zero when there is no usable value.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(n)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// hostPauses remembers until when each host asked to be left alone
+type hostPauses struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// pause holds back requests to host until the given time
+func (p *hostPauses) pause(host string, until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until.After(p.until[host]) {
+		p.until[host] = until
+	}
+}
+
+// Wait sleeps until host may be contacted again, or until ctx is done
+func (p *hostPauses) Wait(ctx context.Context, clock Clock, host string) error {
+	p.mu.Lock()
+	until, ok := p.until[host]
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	delay := until.Sub(clock.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delay returns how long to wait before the given retry, counting from 1
+func (p *RetryPolicy) delay(retry int, rnd *lockedRand) time.Duration {
+	d := float64(p.InitialDelay)
+	factor := p.Factor
+	if factor <= 0 {
+		factor = 1
+	}
+	for i := 1; i < retry; i++ {
+		d *= factor
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rnd.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// lockedRand is a rand.Rand that is safe for concurrent use
+type lockedRand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newLockedRand(src rand.Source) *lockedRand {
+	return &lockedRand{rnd: rand.New(src)}
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Float64()
+}
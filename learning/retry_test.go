@@ -0,0 +1,158 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"success", nil, false},
+		{"canceled", fmt.Errorf("failed to fetch url: %w", context.Canceled), false},
+		{"deadline", markTimeout(context.Background(), context.DeadlineExceeded), false},
+		{"network", errors.New("connection reset by peer"), true},
+		{"empty body", fmt.Errorf("%w: https://example.com", ErrEmptyBody), true},
+		{"truncated body", fmt.Errorf("failed to read response body: %w", errors.New("unexpected EOF")), true},
+		{"429", ErrBadStatus{Code: http.StatusTooManyRequests}, true},
+		{"503", ErrBadStatus{Code: http.StatusServiceUnavailable}, true},
+		{"404", ErrBadStatus{Code: http.StatusNotFound}, false},
+		{"invalid request", fmt.Errorf("%w: bad url", ErrInvalidRequest), false},
+		{"redirect rejected", fmt.Errorf("%w: too many", ErrRedirectRejected), false},
+		{"hook", &HookError{Hook: "request", Err: errors.New("no")}, false},
+		{"too large", ErrTooLarge, false},
+		{"content type", &ContentTypeError{URL: "u", ContentType: "image/png"}, false},
+		{"robots", fmt.Errorf("%w: u", ErrRobotsDisallowed), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 May 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: time.Second, Factor: 2, MaxRetryAfter: time.Minute}
+	tests := []struct {
+		name       string
+		retry      int
+		retryAfter time.Duration
+		want       time.Duration
+		wantOK     bool
+	}{
+		{"backoff", 1, 0, time.Second, true},
+		{"backoff grows", 3, 0, 4 * time.Second, true},
+		{"retry after wins", 1, 10 * time.Second, 10 * time.Second, true},
+		{"backoff wins", 3, 2 * time.Second, 4 * time.Second, true},
+		{"retry after too long", 1, 2 * time.Minute, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := policy.retryDelay(tt.retry, Result{RetryAfter: tt.retryAfter}, nil)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryDelay() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestScrapeRetriesEmptyBody(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts++; attempts > 1 {
+			w.Write([]byte("page"))
+		}
+	}))
+	defer srv.Close()
+
+	s := NewSimpleScraper(5*time.Second, WithRejectEmptyBody(),
+		WithRetry(RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}))
+	result := s.ScrapeResult(context.Background(), srv.URL)
+	if result.Err != nil || string(result.Data) != "page" || result.Attempts != 2 {
+		t.Errorf("got %q, %v after %d attempts, want the page after 2", result.Data, result.Err, result.Attempts)
+	}
+}
+
+func TestRandSourceDelays(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 6, InitialDelay: time.Second, Factor: 2, Jitter: 0.5}
+	delays := func(seed int64) []time.Duration {
+		s := NewSimpleScraper(time.Second, WithRetry(policy), WithRandSource(rand.NewSource(seed)))
+		var got []time.Duration
+		for retry := 1; retry < policy.MaxAttempts; retry++ {
+			got = append(got, s.retry.delay(retry, s.rand))
+		}
+		return got
+	}
+
+	first, second := delays(42), delays(42)
+	if !slices.Equal(first, second) {
+		t.Errorf("seed 42 gave %v then %v, want the same delays", first, second)
+	}
+	if other := delays(7); slices.Equal(first, other) {
+		t.Errorf("seeds 42 and 7 both gave %v", first)
+	}
+}
+
+func TestScrapeRetriesTruncatedBody(t *testing.T) {
+	page := strings.Repeat("x", 1000)
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(page)))
+		if attempts++; attempts > 1 {
+			io.WriteString(w, page)
+			return
+		}
+		// promise the whole page, send a part and hang up
+		io.WriteString(w, page[:100])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	s := NewSimpleScraper(5*time.Second, WithRetry(RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}))
+	result := s.ScrapeResult(context.Background(), srv.URL)
+	if result.Err != nil || string(result.Data) != page || result.Attempts != 2 {
+		t.Errorf("got %d bytes, %v after %d attempts, want the page after 2", len(result.Data), result.Err, result.Attempts)
+	}
+}
@@ -0,0 +1,215 @@
+package learning
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrRobotsDisallowed is returned for urls that robots.txt doesn't allow
+var ErrRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+// RobotsAwareScraper wraps a Scraper and skips urls disallowed by the robots.txt
+// of their host. robots.txt is fetched once per host with the wrapped scraper,
+// a host that answers with a 4xx status allows everything. When robots.txt
+// can't be fetched, or the host answers with a 5xx status, the url fails and
+// the next url of that host tries again.
+type RobotsAwareScraper struct {
+	Scraper   Scraper
+	UserAgent string
+
+	mu    sync.Mutex
+	rules map[string]*robotsRules
+}
+
+// NewRobotsAwareScraper creates a RobotsAwareScraper that follows the rules for userAgent
+func NewRobotsAwareScraper(scraper Scraper, userAgent string) *RobotsAwareScraper {
+	return &RobotsAwareScraper{
+		Scraper:   scraper,
+		UserAgent: userAgent,
+		rules:     map[string]*robotsRules{},
+	}
+}
+
+// Name identifies the RobotsAwareScraper and the scraper it wraps
+func (r *RobotsAwareScraper) Name() string {
+	return "robots(" + ScraperName(r.Scraper) + ")"
+}
+
+// Scrape fetches url if robots.txt allows it
+func (r *RobotsAwareScraper) Scrape(ctx context.Context, rawURL string) ([]byte, error) {
+	allowed, err := r.Allowed(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s", ErrRobotsDisallowed, rawURL)
+	}
+	return r.Scraper.Scrape(ctx, rawURL)
+}
+
+// Allowed reports whether robots.txt allows fetching rawURL
+func (r *RobotsAwareScraper) Allowed(ctx context.Context, rawURL string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	rules, err := r.rulesFor(ctx, u)
+	if err != nil {
+		return false, err
+	}
+	return rules.allowed(u.EscapedPath()), nil
+}
+
+// Close closes the wrapped scraper
+func (r *RobotsAwareScraper) Close() error {
+	return closeScraper(r.Scraper)
+}
+
+func (r *RobotsAwareScraper) rulesFor(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	key := u.Scheme + "://" + u.Host
+
+	r.mu.Lock()
+	rules, ok := r.rules[key]
+	r.mu.Unlock()
+	if ok {
+		return rules, nil
+	}
+
+	data, err := r.Scraper.Scrape(ctx, key+"/robots.txt")
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// don't cache a robots.txt we never got to read
+		return nil, ctxErr
+	}
+	if err != nil {
+		if !missingRobots(err) {
+			return nil, fmt.Errorf("failed to fetch robots.txt of %s: %w", u.Host, err)
+		}
+		data = nil
+	}
+	rules = parseRobots(data, r.UserAgent)
+
+	r.mu.Lock()
+	r.rules[key] = rules
+	r.mu.Unlock()
+	return rules, nil
+}
+
+// missingRobots reports whether err means the host has no robots.txt, which
+// allows everything, rather than that it couldn't be read
+func missingRobots(err error) bool {
+	var bad ErrBadStatus
+	if errors.As(err, &bad) {
+		return bad.Code >= 400 && bad.Code < 500
+	}
+	return errors.Is(err, ErrNotFound)
+}
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	pattern string
+	re      *regexp.Regexp
+	allow   bool
+}
+
+// robotsRules are the rules of the group that applies to our user agent
+type robotsRules struct {
+	rules []robotsRule
+}
+
+// parseRobots picks the group with the longest user-agent that is part of
+// userAgent, or the * group when there is none. A group without rules allows
+// everything.
+func parseRobots(data []byte, userAgent string) *robotsRules {
+	agent := strings.ToLower(userAgent)
+	groups := map[string][]robotsRule{}
+
+	var current []string
+	inRules := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// a user-agent after rules starts a new group
+			if inRules {
+				current = nil
+				inRules = false
+			}
+			ua := strings.ToLower(value)
+			current = append(current, ua)
+			// record the group even if it ends up without rules
+			if _, ok := groups[ua]; !ok {
+				groups[ua] = nil
+			}
+		case "allow", "disallow":
+			inRules = true
+			if key == "disallow" && value == "" {
+				continue
+			}
+			for _, ua := range current {
+				groups[ua] = append(groups[ua], robotsRule{pattern: value, re: robotsPattern(value), allow: key == "allow"})
+			}
+		}
+	}
+
+	best := ""
+	for ua := range groups {
+		if ua != "*" && ua != "" && strings.Contains(agent, ua) &&
+			(len(ua) > len(best) || (len(ua) == len(best) && ua < best)) {
+			best = ua
+		}
+	}
+	if best != "" {
+		return &robotsRules{rules: groups[best]}
+	}
+	return &robotsRules{rules: groups["*"]}
+}
+
+// allowed applies the longest matching rule, allow wins a tie
+func (r *robotsRules) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > best || (len(rule.pattern) == best && rule.allow) {
+			best, allow = len(rule.pattern), rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsPattern turns a robots.txt path pattern with * wildcards and an
+// optional $ end anchor into a regexp
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,126 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseRobots(t *testing.T) {
+	const robots = `
+User-agent: *
+Disallow: /private
+Allow: /private/open
+
+User-agent: googlebot
+Disallow:
+
+User-agent: googlebot-news
+Disallow: /
+
+User-agent: scraper
+User-agent: crawler
+Disallow: /*.pdf$
+Disallow: /tmp # comment
+`
+	tests := []struct {
+		agent string
+		path  string
+		want  bool
+	}{
+		{"", "/", true},
+		{"", "/private/page", false},
+		{"", "/private/open/page", true},
+		{"mybot/1.0", "/private", false},
+		// an empty Disallow allows everything instead of falling back to *
+		{"Googlebot/2.1", "/private", true},
+		// the longest matching user-agent wins
+		{"Googlebot-News", "/", false},
+		{"my-scraper", "/docs/a.pdf", false},
+		{"my-scraper", "/docs/a.pdf?x", true},
+		{"crawler", "/tmp/file", false},
+		{"crawler", "/private", true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.agent, tt.path), func(t *testing.T) {
+			// map iteration order must not change the outcome
+			for range 10 {
+				if got := parseRobots([]byte(robots), tt.agent).allowed(tt.path); got != tt.want {
+					t.Fatalf("allowed(%q) for %q = %v, want %v", tt.path, tt.agent, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/", "/anything", false},
+		{"/a*b", "/axxb/c", false},
+		{"/a*b", "/ac", true},
+		{"/page$", "/page", false},
+		{"/page$", "/page/", true},
+	}
+	for _, tt := range tests {
+		rules := parseRobots([]byte("User-agent: *\nDisallow: "+tt.pattern), "")
+		if got := rules.allowed(tt.path); got != tt.want {
+			t.Errorf("Disallow: %s, allowed(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+// robotsServer answers robots.txt requests with its errors in turn, then with body
+type robotsServer struct {
+	errs    []error
+	body    string
+	fetches int
+}
+
+func (s *robotsServer) Scrape(ctx context.Context, url string) ([]byte, error) {
+	if url != "https://example.com/robots.txt" {
+		return []byte("page"), nil
+	}
+	s.fetches++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	return []byte(s.body), nil
+}
+
+func TestRobotsAwareScraperFetchErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantErr     bool
+		wantFetches int
+	}{
+		{"not found allows everything", ErrBadStatus{Code: 404}, false, 1},
+		{"server error is retried", ErrBadStatus{Code: 503}, true, 2},
+		{"network error is retried", errors.New("connection refused"), true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &robotsServer{errs: []error{tt.err}, body: "User-agent: *\nDisallow: /"}
+			r := NewRobotsAwareScraper(server, "bot")
+
+			_, err := r.Scrape(context.Background(), "https://example.com/page")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("first Scrape error = %v, want error %v", err, tt.wantErr)
+			}
+			_, err = r.Scrape(context.Background(), "https://example.com/page")
+			if tt.wantErr && !errors.Is(err, ErrRobotsDisallowed) {
+				t.Errorf("second Scrape error = %v, want %v", err, ErrRobotsDisallowed)
+			}
+			if server.fetches != tt.wantFetches {
+				t.Errorf("robots.txt fetched %d times, want %d", server.fetches, tt.wantFetches)
+			}
+		})
+	}
+}
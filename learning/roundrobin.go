@@ -0,0 +1,118 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoBackends is returned by scrapers that were created without backends
+var ErrNoBackends = errors.New("no backends configured")
+
+// Backend is a scraper together with the share of the traffic it should get
+type Backend struct {
+	Scraper Scraper
+	Weight  int
+}
+
+// RoundRobinScraper spreads scrapes across several backends by weight.
+// A backend that fails MaxFailures times in a row is skipped for Cooldown.
+type RoundRobinScraper struct {
+	MaxFailures int
+	Cooldown    time.Duration
+	// Clock measures the cool-down, RealClock when nil
+	Clock Clock
+
+	mu       sync.Mutex
+	backends []*rrBackend
+}
+
+type rrBackend struct {
+	Backend
+	current   int
+	failures  int
+	skipUntil time.Time
+}
+
+// NewRoundRobinScraper creates a RoundRobinScraper, backends without a weight get weight 1
+func NewRoundRobinScraper(backends ...Backend) *RoundRobinScraper {
+	r := &RoundRobinScraper{MaxFailures: 3, Cooldown: 30 * time.Second}
+	for _, b := range backends {
+		if b.Weight <= 0 {
+			b.Weight = 1
+		}
+		r.backends = append(r.backends, &rrBackend{Backend: b})
+	}
+	return r
+}
+
+// Name identifies the RoundRobinScraper
+func (r *RoundRobinScraper) Name() string {
+	return "roundrobin"
+}
+
+// Scrape fetches url with the next backend in turn
+func (r *RoundRobinScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	b := r.pick(r.now())
+	if b == nil {
+		return nil, ErrNoBackends
+	}
+
+	data, err := b.Scraper.Scrape(ctx, url)
+	r.report(b, err, r.now())
+	return data, err
+}
+
+func (r *RoundRobinScraper) now() time.Time {
+	if r.Clock == nil {
+		return RealClock.Now()
+	}
+	return r.Clock.Now()
+}
+
+// pick does a smooth weighted round robin over the backends that aren't cooling down,
+// when all of them are cooling down every backend is considered again
+func (r *RoundRobinScraper) pick(now time.Time) *rrBackend {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var available []*rrBackend
+	for _, b := range r.backends {
+		if !now.Before(b.skipUntil) {
+			available = append(available, b)
+		}
+	}
+	if len(available) == 0 {
+		available = r.backends
+	}
+
+	var best *rrBackend
+	total := 0
+	for _, b := range available {
+		b.current += b.Weight
+		total += b.Weight
+		if best == nil || b.current > best.current {
+			best = b
+		}
+	}
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+func (r *RoundRobinScraper) report(b *rrBackend, err error, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if r.MaxFailures > 0 && b.failures >= r.MaxFailures {
+		b.failures = 0
+		b.skipUntil = now.Add(r.Cooldown)
+	}
+}
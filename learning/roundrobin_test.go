@@ -0,0 +1,70 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRoundRobinWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+		scrapes int
+		want    []int
+	}{
+		{"equal", []int{1, 1}, 4, []int{2, 2}},
+		{"weighted", []int{3, 1}, 8, []int{6, 2}},
+		{"zero is one", []int{0, 2}, 6, []int{2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scrapers []*failingScraper
+			var backends []Backend
+			for _, w := range tt.weights {
+				s := &failingScraper{}
+				scrapers = append(scrapers, s)
+				backends = append(backends, Backend{Scraper: s, Weight: w})
+			}
+			r := NewRoundRobinScraper(backends...)
+			for range tt.scrapes {
+				r.Scrape(context.Background(), "https://example.com/")
+			}
+			for i, s := range scrapers {
+				if s.calls != tt.want[i] {
+					t.Errorf("backend %d got %d scrapes, want %d", i, s.calls, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRoundRobinCooldown(t *testing.T) {
+	clock := NewMockClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	broken := &failingScraper{err: errors.New("down")}
+	healthy := &failingScraper{}
+	r := NewRoundRobinScraper(Backend{Scraper: broken}, Backend{Scraper: healthy})
+	r.MaxFailures, r.Cooldown, r.Clock = 2, time.Minute, clock
+
+	scrape := func(n int) {
+		for range n {
+			r.Scrape(context.Background(), "https://example.com/")
+		}
+	}
+	// the broken backend fails twice and cools down, the healthy one takes over
+	scrape(8)
+	if broken.calls != 2 || healthy.calls != 6 {
+		t.Fatalf("broken %d, healthy %d scrapes, want 2 and 6", broken.calls, healthy.calls)
+	}
+
+	clock.Advance(time.Minute)
+	scrape(2)
+	if broken.calls != 3 {
+		t.Errorf("broken got %d scrapes after the cool-down, want 3", broken.calls)
+	}
+
+	if _, err := NewRoundRobinScraper().Scrape(context.Background(), "u"); !errors.Is(err, ErrNoBackends) {
+		t.Errorf("got %v without backends, want ErrNoBackends", err)
+	}
+}
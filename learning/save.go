@@ -0,0 +1,90 @@
+package learning
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxNameLength keeps readable file names well below file system limits
+const maxNameLength = 100
+
+// FileNaming turns a url into the name of the file its body is saved in
+type FileNaming func(url string) string
+
+// HashName names a file after the sha256 of the url
+func HashName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// SafeName builds a readable file name from the url, every character that isn't
+// a letter, digit, dot, dash or underscore is replaced and a short hash of the
+// full url is appended so two different urls never end up in the same file
+func SafeName(url string) string {
+	readable := url
+	if i := strings.Index(readable, "://"); i >= 0 {
+		readable = readable[i+3:]
+	}
+
+	var b strings.Builder
+	for _, r := range readable {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == '.' && b.Len() > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+		if b.Len() >= maxNameLength {
+			break
+		}
+	}
+
+	return b.String() + "-" + HashName(url)[:12]
+}
+
+// SaveResults writes the body of every successful result to its own file in dir,
+// naming defaults to SafeName
+func SaveResults(dir string, results []Result, naming FileNaming) error {
+	if naming == nil {
+		naming = SafeName
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		name := naming(result.URL)
+		if err := validFileName(name); err != nil {
+			return fmt.Errorf("unsafe file name for %s: %w", result.URL, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), result.Data, 0o644); err != nil {
+			return fmt.Errorf("failed to save %s: %w", result.URL, err)
+		}
+	}
+
+	return nil
+}
+
+// validFileName makes sure name is a single path element that stays inside its directory
+func validFileName(name string) error {
+	switch {
+	case name == "", name == ".", name == "..":
+		return fmt.Errorf("invalid name %q", name)
+	case strings.ContainsAny(name, `/\`), filepath.Base(name) != name:
+		return fmt.Errorf("name %q contains a path separator", name)
+	case strings.IndexFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0:
+		return fmt.Errorf("name %q contains control characters", name)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package learning
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSafeName(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string // readable part before the hash
+	}{
+		{"plain", "https://example.com/a/b.html", "example.com_a_b.html"},
+		{"dot dot", "https://../../etc/passwd", "_._.._etc_passwd"},
+		{"only dots", "..", "_."},
+		{"backslash", `https://example.com\..\x`, "example.com_.._x"},
+		{"nul", "https://example.com/a\x00b", "example.com_a_b"},
+		{"unicode", "https://example.com/é", "example.com__"},
+		{"long", "https://example.com/" + strings.Repeat("a", 500), ("example.com_" + strings.Repeat("a", 500))[:maxNameLength]},
+	}
+	seen := map[string]string{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SafeName(tt.url)
+			if want := tt.want + "-" + HashName(tt.url)[:12]; got != want {
+				t.Errorf("SafeName(%q) = %q, want %q", tt.url, got, want)
+			}
+			if err := validFileName(got); err != nil {
+				t.Error(err)
+			}
+			if other, ok := seen[got]; ok {
+				t.Errorf("%q and %q share the name %q", tt.url, other, got)
+			}
+			seen[got] = tt.url
+		})
+	}
+}
+
+func TestSaveResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		naming  FileNaming
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"hash", HashName, false},
+		{"dot dot", func(string) string { return ".." }, true},
+		{"separator", func(string) string { return "../escape" }, true},
+		{"backslash", func(string) string { return `..\escape` }, true},
+		{"nul", func(string) string { return "a\x00b" }, true},
+		{"empty", func(string) string { return "" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "out")
+			results := []Result{{URL: "https://example.com/", Data: []byte("page")}}
+
+			err := SaveResults(dir, results, tt.naming)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SaveResults() error = %v, want error %v", err, tt.wantErr)
+			}
+			// nothing may be written next to dir
+			entries, _ := os.ReadDir(root)
+			if len(entries) != 1 || entries[0].Name() != "out" {
+				t.Errorf("%s holds %v, want only out", root, entries)
+			}
+			saved, _ := os.ReadDir(dir)
+			if want := map[bool]int{false: 1, true: 0}[tt.wantErr]; len(saved) != want {
+				t.Errorf("saved %d files, want %d", len(saved), want)
+			}
+		})
+	}
+}
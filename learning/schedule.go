@@ -0,0 +1,297 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schedule tells when a scheduled job runs next
+type Schedule interface {
+	// Next returns the first run time after t, the zero time if there is none
+	Next(t time.Time) time.Time
+}
+
+// Every runs a job every d, counting from when the Scheduler started it
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// cronSchedule is a parsed cron expression, each field is a bitset of the
+// values it matches
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// anyDay is set when day of month or day of week is *, the other field
+	// alone decides then instead of either of them matching
+	anyDay bool
+}
+
+// cronMacros are the shorthands ParseCron understands
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard five field cron expression (minute, hour, day of
+// month, month and day of week) with *, lists, ranges and steps, or one of the
+// macros such as @hourly or @daily. "@every 5m" is the same as Every.
+func ParseCron(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("invalid cron interval %q", d)
+		}
+		return Every(every), nil
+	}
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q needs 5 fields, got %d", expr, len(fields))
+	}
+	var (
+		s   cronSchedule
+		err error
+	)
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// both 0 and 7 are sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDay = strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+// parseCronField parses a field such as "*/15", "1-5" or "0,30" into a bitset
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step, hasStep := strings.Cut(part, "/")
+		every := 1
+		if hasStep {
+			n, err := strconv.Atoi(step)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", step)
+			}
+			every = n
+		}
+
+		start, end := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				// "5/15" means from 5 to the end in steps of 15
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += every {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// Next implements Schedule
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// every valid expression matches within a few years, except ones like
+	// february 30th that never do
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<t.Weekday()) != 0
+	if s.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// ScheduledJob is a set of urls the Scheduler scrapes on a Schedule
+type ScheduledJob struct {
+	Name     string
+	URLs     []string
+	Schedule Schedule
+	// Timeout bounds every run, zero means no timeout and a run that takes longer
+	// than the schedule makes the runs due meanwhile skip
+	Timeout time.Duration
+	// Handle receives the results of every run
+	Handle func(ctx context.Context, results []Result)
+}
+
+// Scheduler scrapes its jobs on their schedules. A run that is still going when
+// the next one is due makes that one skip, so runs of a job never overlap.
+type Scheduler struct {
+	Scraper BatchScraper
+	// Clock times the runs, RealClock when nil
+	Clock  Clock
+	Logger Logger
+
+	mu   sync.Mutex
+	jobs []ScheduledJob
+}
+
+// NewScheduler creates a Scheduler that scrapes with scraper
+func NewScheduler(scraper BatchScraper) *Scheduler {
+	return &Scheduler{Scraper: scraper}
+}
+
+// Add adds job to the scheduler, jobs added while Run is going start with the next Run
+func (s *Scheduler) Add(job ScheduledJob) error {
+	if job.Schedule == nil {
+		return fmt.Errorf("job %q has no schedule", job.Name)
+	}
+	if len(job.URLs) == 0 {
+		return fmt.Errorf("job %q has no urls", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.jobs {
+		if other.Name == job.Name {
+			return fmt.Errorf("job %q already exists", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+// Run runs the jobs until ctx is done and then waits for the running ones,
+// whose contexts are cancelled as well
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	jobs := append([]ScheduledJob(nil), s.jobs...)
+	s.mu.Unlock()
+	if len(jobs) == 0 {
+		return errors.New("scheduler has no jobs")
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job, &wg)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// loop starts the runs of job until ctx is done
+func (s *Scheduler) loop(ctx context.Context, job ScheduledJob, wg *sync.WaitGroup) {
+	clock := s.Clock
+	if clock == nil {
+		clock = RealClock
+	}
+
+	var running sync.Mutex
+	next := clock.Now()
+	for {
+		// after falling behind, for example while the machine slept, runs
+		// continue from now instead of catching up on the missed ones
+		if now := clock.Now(); now.After(next) {
+			next = now
+		}
+		if next = job.Schedule.Next(next); next.IsZero() {
+			return
+		}
+		select {
+		case <-clock.After(next.Sub(clock.Now())):
+		case <-ctx.Done():
+			return
+		}
+
+		if !running.TryLock() {
+			s.logger().Warn("skipping scheduled run, the previous one is still going", "job", job.Name, "due", next)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer running.Unlock()
+			s.run(ctx, job)
+		}()
+	}
+}
+
+// run scrapes the urls of job once in a context of its own
+func (s *Scheduler) run(ctx context.Context, job ScheduledJob) {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	results := s.Scraper.ScrapeMany(ctx, job.URLs)
+	if job.Handle != nil {
+		job.Handle(ctx, results)
+	}
+}
+
+func (s *Scheduler) logger() Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
+}
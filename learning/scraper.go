@@ -2,12 +2,27 @@ package learning
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"runtime/debug"
 
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"test/learning/extract"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Scraper defines the interface for scraping web pages
@@ -15,118 +30,1121 @@ type Scraper interface {
 	Scrape(ctx context.Context, url string) ([]byte, error)
 }
 
+// NamedScraper is implemented by scrapers that can identify themselves in logs and metrics
+type NamedScraper interface {
+	Name() string
+}
+
+// ScraperName returns the name of a scraper, falling back to its type
+func ScraperName(s Scraper) string {
+	if named, ok := s.(NamedScraper); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", s)
+}
+
 // SimpleScraper implements the Scraper interface
 type SimpleScraper struct {
 	Client *http.Client
+
+	transport        *http.Transport
+	dialer           *net.Dialer
+	dial             func(ctx context.Context, network, addr string) (net.Conn, error)
+	dns              *DNSCache
+	auth             Authenticator
+	onRequest        []RequestHook
+	onResponse       []ResponseHook
+	onError          []ErrorHook
+	buffers          BufferPool
+	pacer            *ratePacer
+	pauses           *hostPauses
+	stripCredentials bool
+	trace            bool
+	rawBody          bool
+	rejectEmptyBody  bool
+	clock            Clock
+	retry            *RetryPolicy
+	rand             *lockedRand
+	proxies          *ProxyPool
+	header           http.Header
+	contextHeader    func(ctx context.Context) http.Header
+	profiles         *ProfileRotator
+	metrics          *Metrics
+	validators       ValidatorStore
+	maxBodyBytes     int64
+	contentTypes     []string
+	redirects        redirectPolicy
+	httpVersion      HTTPVersion
+	sniff            bool
+	tracer           trace.Tracer
+}
+
+// ErrEmptyBody is returned for an empty body when the scraper was created WithRejectEmptyBody
+var ErrEmptyBody = errors.New("empty response body")
+
+// Option configures a SimpleScraper
+type Option func(*SimpleScraper)
+
+// WithMaxHeaderBytes limits the size of the response headers, a server sending
+// more fails the fetch. Zero keeps the net/http default.
+func WithMaxHeaderBytes(n int64) Option {
+	return func(s *SimpleScraper) {
+		s.transport.MaxResponseHeaderBytes = n
+	}
+}
+
+// WithRawBody returns bodies exactly as the server sent them, compressed bodies
+// are not decoded and Result.Header tells their Content-Encoding
+func WithRawBody() Option {
+	return func(s *SimpleScraper) {
+		s.rawBody = true
+	}
+}
+
+// WithRejectEmptyBody treats a successful response without a body as an ErrEmptyBody failure
+func WithRejectEmptyBody() Option {
+	return func(s *SimpleScraper) {
+		s.rejectEmptyBody = true
+	}
+}
+
+// WithStripCredentials removes the user info from the url reported in Result,
+// by default only the password is masked
+func WithStripCredentials() Option {
+	return func(s *SimpleScraper) {
+		s.stripCredentials = true
+	}
 }
 
 // NewSimpleScraper creates a new SimpleScraper
-func NewSimpleScraper(timeout time.Duration) *SimpleScraper {
-	return &SimpleScraper{
-		Client: &http.Client{Timeout: timeout},
+func NewSimpleScraper(timeout time.Duration, opts ...Option) *SimpleScraper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+	s := &SimpleScraper{
+		Client:    &http.Client{Timeout: timeout, Transport: transport},
+		transport: transport,
+		dialer:    &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		clock:     RealClock,
+		rand:      newLockedRand(rand.NewSource(time.Now().UnixNano())),
+	}
+	transport.DialContext = s.dialContext
+	s.Client.CheckRedirect = s.redirects.checkRedirect
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.configureProtocol()
+	return s
+}
+
+// Name identifies the SimpleScraper
+func (s *SimpleScraper) Name() string {
+	return "simple"
+}
+
+// Close releases the idle keep-alive connections held by the client
+func (s *SimpleScraper) Close() error {
+	s.Client.CloseIdleConnections()
+	return nil
+}
+
+// Reset clears the rate limits remembered by the adaptive rate limit option,
+// so requests run at full speed until a host reports a low limit again
+func (s *SimpleScraper) Reset() {
+	if s.pacer != nil {
+		s.pacer.Reset()
+	}
+}
+
+// closeScraper closes s if it holds resources
+func closeScraper(s Scraper) error {
+	if closer, ok := s.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 // Scrape fetches the contents of a URL
 func (s *SimpleScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	result := s.ScrapeResult(ctx, url)
+	if result.NotModified {
+		return nil, fmt.Errorf("%w: %s", ErrNotModified, result.URL)
+	}
+	return result.Data, result.Err
+}
+
+// ScrapeResult fetches the contents of a URL together with response metadata
+func (s *SimpleScraper) ScrapeResult(ctx context.Context, url string) Result {
+	return s.ScrapeWith(ctx, ScrapeRequest{URL: url})
+}
+
+// ScrapeWith fetches a request with its own method, body, headers and cookies.
+// Requests that aren't idempotent, such as POST, are never retried.
+func (s *SimpleScraper) ScrapeWith(ctx context.Context, sr ScrapeRequest) (result Result) {
+	if s.tracer != nil {
+		var span trace.Span
+		ctx, span = s.tracer.Start(ctx, "scrape",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", sr.method()),
+				attribute.String("url.full", s.reportedURL(sr.URL))))
+		defer func() { endScrapeSpan(span, result) }()
+	}
+
+	return s.withRetries(ctx, sr, func(result *Result) {
+		result.Data, result.Err = s.fetch(ctx, sr, result)
+	})
+}
+
+// withRetries calls try, which fills in the outcome of one attempt, until an
+// attempt succeeds or the retry policy gives up
+func (s *SimpleScraper) withRetries(ctx context.Context, sr ScrapeRequest, try func(result *Result)) (result Result) {
+	attempts := 1
+	if s.retry != nil && s.retry.MaxAttempts > 1 && sr.idempotent() {
+		attempts = s.retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		result = Result{URL: s.reportedURL(sr.URL), Attempts: attempt}
+		start := time.Now()
+		try(&result)
+		result.Err = markTimeout(ctx, result.Err)
+		s.errorHooks(result)
+		if s.metrics != nil {
+			s.metrics.observe("http", result, time.Since(start))
+		}
+		if attempt >= attempts || !retryable(result.Err) {
+			return result
+		}
+
+		delay, ok := s.retry.retryDelay(attempt, result, s.rand)
+		if !ok {
+			return result
+		}
+		select {
+		case <-s.clock.After(delay):
+		case <-ctx.Done():
+			result.Err = markTimeout(ctx, fmt.Errorf("%w (after %d attempts: %w)", ctx.Err(), attempt, result.Err))
+			return result
+		}
 	}
+}
 
-	resp, err := s.Client.Do(req)
+// reportedURL hides the credentials of url so they don't end up in results or logs
+func (s *SimpleScraper) reportedURL(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if s.stripCredentials {
+		u.User = nil
+		return u.String()
+	}
+	return u.Redacted()
+}
+
+// fetch does the request, reads the body and fills the response metadata into result
+func (s *SimpleScraper) fetch(ctx context.Context, sr ScrapeRequest, result *Result) ([]byte, error) {
+	resp, err := s.do(ctx, sr, result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch url %s: %w", url, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status code: %d", resp.StatusCode)
+	buffers := s.buffers
+	if buffers == nil {
+		buffers = defaultBufferPool
 	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(buffers, resp.Body, resp.ContentLength)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if result.NotModified {
+		return nil, nil
+	}
+	if s.rejectEmptyBody && len(body) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyBody, result.URL)
+	}
+	if sr.safe() {
+		if err := s.storeValidators(result.URL, resp.Header); err != nil {
+			return nil, err
+		}
+	}
 
 	return body, nil
 }
 
+// do sends the request and checks the status, the caller must close the body
+func (s *SimpleScraper) do(ctx context.Context, sr ScrapeRequest, result *Result) (*http.Response, error) {
+	req, err := sr.newRequest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	s.applyHeaders(req)
+	sr.apply(req)
+	if sr.safe() {
+		s.setConditional(req, result.URL)
+	}
+
+	// a request that brings its own Authorization header keeps it
+	if s.auth != nil && req.Header.Get("Authorization") == "" && req.URL.User == nil {
+		if err := s.auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("failed to authenticate %s: %w", result.URL, err)
+		}
+	}
+
+	// credentials in the url are sent as basic auth and never as part of the url
+	if user := req.URL.User; user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+		req.URL.User = nil
+	}
+
+	// the transport's own gzip handling is off, we ask for and decode every
+	// encoding ourselves unless the caller picked one
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	if s.tracer != nil {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
+
+	if err := s.requestHooks(req); err != nil {
+		return nil, err
+	}
+
+	if s.trace {
+		result.Trace = &TraceInfo{}
+		req = withTrace(req, result.Trace)
+	}
+
+	if s.pauses != nil {
+		if err := s.pauses.Wait(ctx, s.clock, req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+	if s.pacer != nil {
+		if err := s.pacer.Wait(ctx, s.clock, req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	var reportProxy func(ok bool)
+	if s.proxies != nil {
+		req, reportProxy = s.proxies.withProxy(req)
+	}
+
+	resp, err := s.client(ctx, sr).Do(req)
+	if reportProxy != nil {
+		reportProxy(err == nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url %s: %w", result.URL, err)
+	}
+
+	result.StatusCode = resp.StatusCode
+	result.Redirects = redirectChain(resp)
+	result.Header = resp.Header
+	result.RateLimit = parseRateLimit(resp.Header, s.clock.Now())
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		result.Cookies = cookies
+	}
+	if s.pacer != nil {
+		s.pacer.Update(req.URL.Hostname(), result.RateLimit)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		now := s.clock.Now()
+		result.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), now)
+		if s.pauses != nil && result.RetryAfter > 0 {
+			s.pauses.pause(req.URL.Hostname(), now.Add(result.RetryAfter))
+		}
+	}
+
+	if err := s.responseHooks(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		if inv, ok := s.auth.(invalidator); ok {
+			inv.Invalidate()
+		}
+	}
+	if resp.StatusCode == http.StatusNotModified && conditional(req) {
+		result.NotModified = true
+		return resp, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, ErrBadStatus{Code: resp.StatusCode}
+	}
+
+	if !s.rawBody {
+		if err := decodeBody(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	if err := s.checkProtocol(resp, result.URL); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if err := s.checkContentType(resp, result.URL); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	body, err := s.limitBody(resp.Body, resp.ContentLength, result.URL)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = body
+
+	return resp, nil
+}
+
 // Result holds the result of a scraping operation
 type Result struct {
 	URL  string
 	Data []byte
 	Err  error
+	// StatusCode is the http status of the response, zero when there was none
+	StatusCode int
+	// Attempts is the number of requests made for the url, including retries
+	Attempts int
+	Started  time.Time
+	Duration time.Duration
+	// Header holds the response headers when the scraper reports them
+	Header http.Header
+	// RateLimit is set when the response carried rate limit headers
+	RateLimit *RateLimit
+	// Cookies holds the cookies set by the response, nil when there were none
+	Cookies []*http.Cookie
+	// Trace is set when the scraper was created WithTrace
+	Trace *TraceInfo
+	// Redirects lists the urls the request was redirected to, the last one served the response
+	Redirects []string
+	// Streamed is the number of body bytes handed to a BodyConsumer, Data stays
+	// empty for streamed results
+	Streamed int64
+	// NotModified is set when a conditional request got a 304, Data is empty then
+	NotModified bool
+	// RetryAfter is the wait a 429 or 503 response asked for in its Retry-After header
+	RetryAfter time.Duration
+	// Unchanged is set when the body hashes the same as on the last crawl, see WithChangeDetection
+	Unchanged bool
+}
+
+// ResultScraper is implemented by scrapers that can report response metadata
+// next to the body, Worker prefers it over Scrape
+type ResultScraper interface {
+	ScrapeResult(ctx context.Context, url string) Result
 }
 
 // Worker is a function that processes a single URL
 func Worker(ctx context.Context, scraper Scraper, url string, results chan<- Result) {
+	results <- scrapeOne(ctx, scraper, url)
+}
+
+// scrapeOne scrapes a single url and times it
+func scrapeOne(ctx context.Context, scraper Scraper, url string) Result {
+	start := time.Now()
+	if rs, ok := scraper.(ResultScraper); ok {
+		result := rs.ScrapeResult(ctx, url)
+		result.Started = start
+		result.Duration = time.Since(start)
+		return result
+	}
 	data, err := scraper.Scrape(ctx, url)
-	results <- Result{URL: url, Data: data, Err: err}
+	return Result{URL: url, Data: data, Err: err, Started: start, Duration: time.Since(start)}
 }
 
 // ConcurrentScraper manages concurrent scraping of multiple URLs
 type ConcurrentScraper struct {
 	Scraper    Scraper
 	NumWorkers int
+	// Limiter caps concurrent scrapes, when nil a semaphore sized by NumWorkers is used
+	Limiter ConcurrencyLimiter
+	// HostLimiter caps concurrent scrapes per host on top of Limiter, see WithMaxPerHost
+	HostLimiter *HostLimiter
+	// FailFast cancels the rest of the batch after the first failed url
+	FailFast bool
+	// MaxTotalBytes stops the batch once this many body bytes were fetched, zero means no limit
+	MaxTotalBytes int64
+	// DisableRecovery lets panics in the scraper crash the process, useful when debugging
+	DisableRecovery bool
+	// Discover returns the urls found in a result, they are added to the running batch
+	Discover func(Result) []string
+	// MaxPages caps the number of urls a batch scrapes once Discover adds urls, zero means no limit
+	MaxPages int
+	// Logger receives the scraper's diagnostics, slog.Default() is used when nil
+	Logger Logger
+	// SlowThreshold logs every fetch that takes longer, zero disables it
+	SlowThreshold time.Duration
+	// Consumer receives the streamed bodies when Scraper implements StreamScraper
+	Consumer BodyConsumer
+	// MaxInflight caps the http requests running at once separately from the workers,
+	// which then only limit result processing. Zero lets the workers cap both.
+	MaxInflight int
+	// Progress is called after every finished url
+	Progress ProgressFunc
+	// Store persists every result as soon as it is done
+	Store ResultStore
+	// Metrics records every scraped url when set
+	Metrics *Metrics
+	// Tracer creates a span for every batch when set
+	Tracer trace.Tracer
+	// Deduper is shared by all batches when set, otherwise every batch dedupes on its own
+	Deduper *Deduper
+	// Jobs keeps track of queued and finished urls across runs, see WithJobStore
+	Jobs JobStore
+	// Domains decides which hosts are fetched, see WithDomainFilter
+	Domains *DomainFilter
+	// Priority returns the priority of a url, see WithPriority
+	Priority func(url string) int
+	// Timeout returns the request timeout of a url, see WithURLTimeouts
+	Timeout func(url string) time.Duration
+	// Hashes stores the last content hash of every url, see WithChangeDetection
+	Hashes Cache
+
+	inflight inflightSet
+}
+
+// ErrByteBudgetExceeded is the cancellation cause of a batch that hit MaxTotalBytes
+var ErrByteBudgetExceeded = errors.New("byte budget exceeded")
+
+// ErrFailFast is the cancellation cause of a batch that was aborted by WithFailFast
+var ErrFailFast = errors.New("batch aborted after a failure")
+
+// ConcurrentOption configures a ConcurrentScraper
+type ConcurrentOption func(*ConcurrentScraper)
+
+// WithMaxPerHost allows at most n scrapes of the same host at the same time,
+// independent of the number of workers
+func WithMaxPerHost(n int) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.HostLimiter = NewHostLimiter(n)
+	}
+}
+
+// WithConcurrencyLimiter makes the scraper use a shared limiter instead of its own semaphore
+func WithConcurrencyLimiter(limiter ConcurrencyLimiter) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Limiter = limiter
+	}
+}
+
+// WithFailFast aborts the batch on the first failure, the unfinished urls report
+// ErrFailFast wrapping the error that triggered it
+func WithFailFast() ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.FailFast = true
+	}
+}
+
+// WithMaxTotalBytes caps the body bytes fetched by a batch, once the cap is passed
+// no new urls are started and the ones in flight are cancelled
+func WithMaxTotalBytes(n int64) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.MaxTotalBytes = n
+	}
+}
+
+// WithoutPanicRecovery stops turning panics in the scraper into errors
+func WithoutPanicRecovery() ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.DisableRecovery = true
+	}
+}
+
+// WithDiscover feeds the urls found by discover back into the running batch,
+// every url is scraped once and the batch stops growing after maxPages urls
+func WithDiscover(discover func(Result) []string, maxPages int) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Discover = discover
+		c.MaxPages = maxPages
+	}
+}
+
+// WithMaxInflight allows n requests in flight independent of the number of workers
+func WithMaxInflight(n int) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.MaxInflight = n
+	}
 }
 
 // NewConcurrentScraper creates a new ConcurrentScraper
-func NewConcurrentScraper(scraper Scraper, numWorkers int) *ConcurrentScraper {
-	return &ConcurrentScraper{Scraper: scraper, NumWorkers: numWorkers}
+func NewConcurrentScraper(scraper Scraper, numWorkers int, opts ...ConcurrentOption) *ConcurrentScraper {
+	c := &ConcurrentScraper{Scraper: scraper, NumWorkers: numWorkers}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Close closes the underlying scraper if it holds resources
+func (c *ConcurrentScraper) Close() error {
+	return closeScraper(c.Scraper)
+}
+
+// ScrapeMany implements BatchScraper
+func (c *ConcurrentScraper) ScrapeMany(ctx context.Context, urls []string) []Result {
+	return c.Scrape(ctx, urls)
 }
 
-// Scrape concurrently scrapes multiple URLs
+// Scrape concurrently scrapes multiple URLs.
+// Urls that didn't finish because the batch was stopped carry context.Cause in
+// their error, telling a deadline, a cancellation and a fail-fast abort apart.
+// With Discover set, the urls it returns are scraped in the same batch.
 func (c *ConcurrentScraper) Scrape(ctx context.Context, urls []string) []Result {
-	var wg sync.WaitGroup
-	results := make(chan Result, len(urls))
+	results, _ := c.ScrapeWithError(ctx, urls)
+	return results
+}
+
+// ScrapeWithError works like Scrape and also reports why a batch ended early or
+// what went wrong inside the pool. The results collected so far are always returned.
+func (c *ConcurrentScraper) ScrapeWithError(ctx context.Context, urls []string) ([]Result, error) {
+	b := c.newBatch(ctx)
+	defer b.cancel(nil)
+
+	err := c.run(b, c.prioritized(urls))
+	return b.results, err
+}
+
+// ScrapeStream works like Scrape but sends every result on the returned channel
+// as soon as it is done, the channel is closed when the batch is finished.
+// Once ctx is cancelled results that nobody receives anymore are dropped.
+// Results are not collected, at most NumWorkers of them wait in the channel.
+func (c *ConcurrentScraper) ScrapeStream(ctx context.Context, urls []string) <-chan Result {
+	out := make(chan Result, max(c.NumWorkers, 1))
+
+	b := c.newBatch(ctx)
+	b.emit = func(result Result) {
+		select {
+		case out <- result:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(out)
+		defer b.cancel(nil)
+		c.run(b, c.prioritized(urls))
+	}()
+	return out
+}
+
+// ScrapeEach works like ScrapeWithError but hands every result to handle as
+// soon as it is done instead of collecting them, for crawls too big to keep in
+// memory. handle runs on the worker that scraped the url, so it must be safe
+// for concurrent use, and nothing holds on to the result once it returns: a
+// body that handle doesn't keep is garbage right away. Memory then stays flat
+// apart from the urls the Deduper remembers. NewBloomDeduper bounds those too,
+// at the price of skipping the odd url it mistakes for a seen one.
+func (c *ConcurrentScraper) ScrapeEach(ctx context.Context, urls []string, handle func(Result)) error {
+	b := c.newBatch(ctx)
+	defer b.cancel(nil)
+
+	b.emit = handle
+	return c.run(b, c.prioritized(urls))
+}
+
+func (c *ConcurrentScraper) newBatch(ctx context.Context) *batch {
+	var span trace.Span
+	if c.Tracer != nil {
+		ctx, span = c.Tracer.Start(ctx, "scrape batch")
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	b := &batch{
+		ctx:      ctx,
+		cancel:   cancel,
+		limiter:  c.Limiter,
+		seen:     c.Deduper,
+		wake:     make(chan struct{}, 1),
+		progress: c.Progress,
+		store:    c.Store,
+		span:     span,
+	}
+	if b.seen == nil {
+		b.seen = NewDeduper()
+	}
+	if b.limiter == nil {
+		// a semaphore without slots would block every url forever
+		b.limiter = NewSemaphore(max(c.NumWorkers, 1))
+	}
+	if c.MaxInflight > 0 {
+		b.requests = NewSemaphore(c.MaxInflight)
+	}
+	return b
+}
+
+// run scrapes urls in b and waits until every url, including the discovered ones, is done
+func (c *ConcurrentScraper) run(b *batch, urls []PriorityURL) error {
+	defer c.inflight.register(b)()
+	if c.Jobs != nil {
+		var err error
+		if urls, err = c.pendingJobs(b.ctx, urls); err != nil {
+			b.fail(err)
+		}
+	}
+
+	raw := make([]string, len(urls))
+	priorities := make(map[string]int, len(urls))
+	for i, u := range urls {
+		raw[i] = u.URL
+		// a url listed twice keeps its highest priority
+		key := normalizeOrRaw(u.URL)
+		if p, ok := priorities[key]; !ok || u.Priority > p {
+			priorities[key] = u.Priority
+		}
+	}
 
-	semaphore := make(chan struct{}, c.NumWorkers)
-	for _, url := range urls {
-		wg.Add(1)
-		go func(url string) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			Worker(ctx, c.Scraper, url, results)
-		}(url)
+	plan := c.plan(raw)
+	b.mu.Lock()
+	b.total = len(urls)
+	b.mu.Unlock()
+	for _, skipped := range plan.Skipped {
+		b.add(Result{URL: skipped.URL, Err: skipped.Err()})
+	}
+	for _, url := range plan.Fetch {
+		if !b.visit(url, 0) {
+			b.add(Result{URL: url, Err: SkippedURL{URL: url, Reason: "already scraped"}.Err()})
+			continue
+		}
+		if c.Jobs != nil {
+			if finished, err := c.Jobs.Finished(b.ctx, url); err != nil {
+				b.fail(err)
+			} else if finished {
+				b.add(Result{URL: url, Err: SkippedURL{URL: url, Reason: "already finished"}.Err()})
+				continue
+			}
+		}
+		priority := priorities[normalizeOrRaw(url)]
+		c.queueJob(b, url, priority)
+		b.mu.Lock()
+		b.push(url, priority)
+		b.mu.Unlock()
 	}
 
-	wg.Wait()
-	close(results)
+	// with MaxInflight above NumWorkers the extra workers only wait for requests,
+	// the worker limiter still caps the processing of their results
+	jobs := make(chan string)
+	for range max(c.NumWorkers, c.MaxInflight, 1) {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			for url := range jobs {
+				c.process(b, url)
+				b.done()
+			}
+		}()
+	}
+	b.dispatch(jobs)
+	b.wg.Wait()
+	// urls discovered while the batch was stopping are still queued
+	b.abandon()
 
-	var finalResults []Result
-	for result := range results {
-		finalResults = append(finalResults, result)
+	err := errors.Join(b.errs...)
+	if b.ctx.Err() != nil {
+		err = errors.Join(fmt.Errorf("batch stopped early: %w", context.Cause(b.ctx)), err)
+	}
+	if b.span != nil {
+		b.span.SetAttributes(attribute.Int("scrape.urls", b.total))
+		if err != nil {
+			b.span.RecordError(err)
+			b.span.SetStatus(codes.Error, err.Error())
+		}
+		b.span.End()
 	}
+	return err
+}
 
-	return finalResults
+// batch holds the state shared by the urls of a single Scrape call
+type batch struct {
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	limiter    ConcurrencyLimiter
+	requests   ConcurrencyLimiter
+	totalBytes atomic.Int64
+	wg         sync.WaitGroup
+	// emit receives the results instead of collecting them when set
+	emit func(Result)
+	// store saves every result when set
+	store ResultStore
+	// span covers the whole batch when tracing is on
+	span trace.Span
+	// pending counts the jobs handed to workers that aren't done yet
+	pending atomic.Int64
+	// wake tells dispatch that urls were queued or a job finished
+	wake chan struct{}
+	// progress is told about every finished url, calls are serialized by progressMu
+	progress   ProgressFunc
+	progressMu sync.Mutex
+	finished   int
+
+	mu      sync.Mutex
+	results []Result
+	seen    *Deduper
+	pages   int
+	// filtered holds the discovered urls already reported as filtered
+	filtered map[string]bool
+	queue    urlQueue
+	// waiting holds the queued urls no worker started on yet, cancelled the
+	// ones of them CancelURL was called for
+	waiting   map[string]bool
+	cancelled map[string]bool
+	total     int
+	errs      []error
 }
 
-// processResults processes the results of scraping
-func ProcessResults(results []Result) {
-	for _, result := range results {
-		if result.Err != nil {
-			fmt.Printf("Failed to fetch %s: %v\n", result.URL, result.Err)
+// dispatch hands the queued urls to the workers, highest priority first. It
+// closes jobs once nothing is queued and no job can discover more urls, or as
+// soon as the batch is stopped.
+func (b *batch) dispatch(jobs chan<- string) {
+	defer close(jobs)
+	for b.ctx.Err() == nil {
+		url, ok := b.dequeue()
+		if !ok {
+			if b.pending.Load() == 0 {
+				return
+			}
+			select {
+			case <-b.wake:
+			case <-b.ctx.Done():
+			}
 			continue
 		}
-		fmt.Printf("Fetched %d bytes from %s\n", len(result.Data), result.URL)
+
+		b.pending.Add(1)
+		select {
+		case jobs <- url:
+		case <-b.ctx.Done():
+			b.pending.Add(-1)
+			b.add(b.stopped(url))
+		}
 	}
 }
 
+// abandon reports the urls still queued in a stopped batch as stopped
+func (b *batch) abandon() {
+	for {
+		url, ok := b.dequeue()
+		if !ok {
+			return
+		}
+		b.add(b.stopped(url))
+	}
+}
+
+// stopped is the result of a url the stopped batch never got to
+func (b *batch) stopped(url string) Result {
+	return Result{URL: url, Err: withCause(b.ctx, b.ctx.Err())}
+}
+
+// enqueue queues a discovered url for dispatch
+func (b *batch) enqueue(url string, priority int) {
+	b.mu.Lock()
+	b.push(url, priority)
+	b.total++
+	b.mu.Unlock()
+	b.signal()
+}
+
+func (b *batch) dequeue() (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queue.pop()
+}
+
+// push queues url, b.mu must be held
+func (b *batch) push(url string, priority int) {
+	b.queue.push(url, priority)
+	if b.waiting == nil {
+		b.waiting = map[string]bool{}
+	}
+	b.waiting[url] = true
+}
+
+// cancelQueued marks url as cancelled if it is queued or on its way to a
+// worker, it reports whether it was
+func (b *batch) cancelQueued(url string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.waiting[url] {
+		return false
+	}
+	if b.cancelled == nil {
+		b.cancelled = map[string]bool{}
+	}
+	b.cancelled[url] = true
+	return true
+}
+
+// claim takes url off the waiting urls when a worker starts on it, it reports
+// whether url was cancelled while it waited
+func (b *batch) claim(url string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.waiting, url)
+	if b.cancelled[url] {
+		delete(b.cancelled, url)
+		return true
+	}
+	return false
+}
+
+// done marks a job as finished, after the urls it discovered were queued
+func (b *batch) done() {
+	b.pending.Add(-1)
+	b.signal()
+}
+
+func (b *batch) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *batch) fail(err error) {
+	b.mu.Lock()
+	b.errs = append(b.errs, err)
+	b.mu.Unlock()
+}
+
+func (b *batch) add(result Result) {
+	if b.store != nil {
+		// results of a stopped batch are still worth keeping
+		if err := b.store.Save(context.WithoutCancel(b.ctx), result); err != nil {
+			b.fail(err)
+		}
+	}
+	if b.emit != nil {
+		b.emit(result)
+	} else {
+		b.mu.Lock()
+		b.results = append(b.results, result)
+		b.mu.Unlock()
+	}
+	b.reportProgress(result)
+}
+
+// visit marks url as seen, it returns false if it was seen before or the
+// batch already holds maxPages urls
+func (b *batch) visit(url string, maxPages int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if (maxPages > 0 && b.pages >= maxPages) || !b.seen.Add(url) {
+		return false
+	}
+	b.pages++
+	return true
+}
+
+// filter reports a discovered url the DomainFilter rejected, once per batch
+func (b *batch) filter(url string, err error) {
+	key := normalizeOrRaw(url)
+	b.mu.Lock()
+	if b.filtered[key] {
+		b.mu.Unlock()
+		return
+	}
+	if b.filtered == nil {
+		b.filtered = map[string]bool{}
+	}
+	b.filtered[key] = true
+	b.total++
+	b.mu.Unlock()
+	b.add(Result{URL: url, Err: err})
+}
+
+// process scrapes url on a worker and queues the urls discovered on it
+func (c *ConcurrentScraper) process(b *batch, url string) {
+	added := false
+	if !c.DisableRecovery {
+		// a panic outside the scraper, for example in Discover, must not lose the batch
+		defer func() {
+			if v := recover(); v != nil {
+				err := &PanicError{Value: v, Stack: debug.Stack()}
+				b.fail(fmt.Errorf("worker for %s: %w", url, err))
+				if !added {
+					b.add(Result{URL: url, Err: err})
+				}
+			}
+		}()
+	}
+
+	if b.claim(url) {
+		result := cancelledResult(url)
+		b.add(result)
+		added = true
+		c.finishJob(b, url, result)
+		return
+	}
+	if b.ctx.Err() != nil {
+		// the scraper might not look at ctx before doing the work
+		b.add(b.stopped(url))
+		added = true
+		return
+	}
+	result := c.scrapeURL(b, url)
+	b.add(result)
+	added = true
+	if c.Discover != nil && result.Err == nil && b.ctx.Err() == nil {
+		c.discover(b, result)
+	}
+	// only after its discovered urls are queued, or a crash in between loses them
+	c.finishJob(b, url, result)
+}
+
+// discover queues the new urls found in result. When requests have their own
+// limit, the worker limiter only gates this processing step.
+func (c *ConcurrentScraper) discover(b *batch, result Result) {
+	if b.requests != nil {
+		if err := b.limiter.Acquire(b.ctx); err != nil {
+			return
+		}
+		defer b.limiter.Release()
+	}
+
+	for _, next := range c.Discover(result) {
+		if c.skipReason(next) != "" {
+			continue
+		}
+		if err := c.Domains.Check(next); err != nil {
+			b.filter(next, err)
+			continue
+		}
+		if b.visit(next, c.MaxPages) {
+			priority := c.priority(next)
+			c.queueJob(b, next, priority)
+			b.enqueue(next, priority)
+		}
+	}
+}
+
+// scrapeURL scrapes a single url of a batch
+func (c *ConcurrentScraper) scrapeURL(b *batch, url string) Result {
+	ctx, cancel := context.WithCancelCause(b.ctx)
+	defer c.inflight.track(url, cancel)()
+	if c.Timeout != nil {
+		if d := c.Timeout(url); d > 0 {
+			ctx = WithRequestTimeout(ctx, d)
+		}
+	}
+
+	// the host slot comes first, so urls waiting for a busy host don't hold
+	// slots other hosts could use
+	if c.HostLimiter != nil {
+		host := hostOf(url)
+		if err := c.HostLimiter.Acquire(ctx, host); err != nil {
+			return Result{URL: url, Err: withCause(ctx, err)}
+		}
+		defer c.HostLimiter.Release(host)
+	}
+
+	limiter := b.limiter
+	if b.requests != nil {
+		limiter = b.requests
+	}
+	if err := limiter.Acquire(ctx); err != nil {
+		return Result{URL: url, Err: withCause(ctx, err)}
+	}
+	defer limiter.Release()
+
+	result := c.recoverScrape(ctx, url)
+	if c.Metrics != nil {
+		c.Metrics.observe("batch", result, result.Duration)
+	}
+	if c.SlowThreshold > 0 && result.Duration > c.SlowThreshold {
+		c.logger().Warn("slow request", "url", result.URL, "duration", result.Duration)
+	}
+	switch {
+	case result.Err == nil:
+		c.checkUnchanged(&result)
+		total := b.totalBytes.Add(int64(len(result.Data)) + result.Streamed)
+		if c.MaxTotalBytes > 0 && total > c.MaxTotalBytes {
+			b.cancel(ErrByteBudgetExceeded)
+		}
+	case ctx.Err() != nil:
+		result.Err = markTimeout(ctx, withCause(ctx, result.Err))
+	case c.FailFast:
+		b.cancel(fmt.Errorf("%w: %s: %w", ErrFailFast, url, result.Err))
+	}
+	return result
+}
+
+// recoverScrape scrapes url and turns a panic into a PanicError result
+func (c *ConcurrentScraper) recoverScrape(ctx context.Context, url string) (result Result) {
+	if !c.DisableRecovery {
+		defer func() {
+			if v := recover(); v != nil {
+				result = Result{URL: url, Err: &PanicError{Value: v, Stack: debug.Stack()}}
+			}
+		}()
+	}
+	if stream, ok := c.Scraper.(StreamScraper); ok && c.Consumer != nil {
+		return streamOne(ctx, stream, url, c.Consumer)
+	}
+	return scrapeOne(ctx, c.Scraper, url)
+}
+
+// PanicError is the error of a url whose scraper panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("scraper panicked: %v", e.Value)
+}
+
+// withCause adds the reason ctx was stopped to err, unless err already carries it
+func withCause(ctx context.Context, err error) error {
+	cause := context.Cause(ctx)
+	if cause == nil || errors.Is(err, cause) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", cause, err)
+}
+
+// ProcessResults logs the results of scraping to logger, extractors pull
+// structured fields out of every fetched page. slog.Default() is used when
+// logger is nil, use a ResultLogger to pick the levels.
+func ProcessResults(logger *slog.Logger, results []Result, extractors ...extract.Extractor) {
+	NewResultLogger(logger).Process(context.Background(), results, extractors...)
+}
+
 func ScraperExec() {
+	scraperExec(NewSimpleScraper(10 * time.Second))
+}
+
+// ScraperExecOffline runs the same demo against canned pages, so it works without network access
+func ScraperExecOffline() {
+	scraperExec(NewStubScraper(map[string][]byte{
+		"https://example.com": []byte("<html><title>Example Domain</title></html>"),
+		"https://golang.org":  []byte("<html><title>The Go Programming Language</title></html>"),
+	}))
+}
+
+func scraperExec(scraper Scraper) {
 	urls := []string{
 		"https://example.com",
 		"https://golang.org",
 		"https://github.com",
 	}
 
-	scraper := NewSimpleScraper(10 * time.Second)
 	concurrentScraper := NewConcurrentScraper(scraper, 5)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	results := concurrentScraper.Scrape(ctx, urls)
-	ProcessResults(results)
+	ProcessResults(nil, results)
 }
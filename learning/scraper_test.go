@@ -0,0 +1,505 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScrapeReportsURLsAsGiven(t *testing.T) {
+	stub := NewStubScraper(map[string][]byte{
+		"https://example.com": []byte("page"),
+		"https://golang.org":  []byte("go"),
+	})
+	results := NewConcurrentScraper(stub, 2).Scrape(context.Background(),
+		[]string{"https://example.com", "https://golang.org", "https://EXAMPLE.com/"})
+
+	var got []string
+	for _, result := range results {
+		switch {
+		case result.Err == nil:
+			got = append(got, result.URL)
+		case !errors.Is(result.Err, ErrSkipped):
+			t.Errorf("%s: %v", result.URL, result.Err)
+		}
+	}
+	slices.Sort(got)
+	if want := []string{"https://example.com", "https://golang.org"}; !slices.Equal(got, want) {
+		t.Errorf("fetched %q, want %q", got, want)
+	}
+}
+
+func TestCloseReleasesIdleConnections(t *testing.T) {
+	tests := []struct {
+		name string
+		wrap func(Scraper) Scraper
+	}{
+		{"simple", func(s Scraper) Scraper { return s }},
+		{"cached", func(s Scraper) Scraper { return NewCachingScraper(s, NewMemoryCache(10), time.Minute) }},
+		{"singleflight", func(s Scraper) Scraper { return NewSingleflightScraper(s) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed := make(chan struct{}, 1)
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("page"))
+			}))
+			srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateClosed {
+					closed <- struct{}{}
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+
+			s := tt.wrap(NewSimpleScraper(5 * time.Second))
+			if _, err := s.Scrape(context.Background(), srv.URL); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-closed:
+				t.Fatal("the connection was closed before Close")
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			if err := s.(io.Closer).Close(); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-closed:
+			case <-time.After(5 * time.Second):
+				t.Error("the idle connection is still open after Close")
+			}
+		})
+	}
+}
+
+func TestResultCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+	}{
+		{"none", nil},
+		{"two", []*http.Cookie{{Name: "session", Value: "abc"}, {Name: "theme", Value: "dark", Path: "/"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for _, c := range tt.cookies {
+					http.SetCookie(w, c)
+				}
+			}))
+			defer srv.Close()
+
+			result := NewSimpleScraper(5*time.Second).ScrapeResult(context.Background(), srv.URL)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if tt.cookies == nil {
+				if result.Cookies != nil {
+					t.Errorf("Cookies = %v, want nil", result.Cookies)
+				}
+				return
+			}
+			if len(result.Cookies) != len(tt.cookies) {
+				t.Fatalf("got %d cookies, want %d", len(result.Cookies), len(tt.cookies))
+			}
+			for i, c := range result.Cookies {
+				if c.Name != tt.cookies[i].Name || c.Value != tt.cookies[i].Value {
+					t.Errorf("cookie %d = %s=%s, want %s=%s", i, c.Name, c.Value, tt.cookies[i].Name, tt.cookies[i].Value)
+				}
+			}
+		})
+	}
+}
+
+func TestURLBasicAuth(t *testing.T) {
+	type auth struct {
+		user, password string
+		ok             bool
+	}
+	got := make(chan auth, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		got <- auth{user, password, ok}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	tests := []struct {
+		name     string
+		url      string
+		opts     []Option
+		want     auth
+		reported string
+	}{
+		{"none", "http://" + host + "/", nil, auth{}, "http://" + host + "/"},
+		{"user and password", "http://alice:s3cret@" + host + "/", nil,
+			auth{"alice", "s3cret", true}, "http://alice:xxxxx@" + host + "/"},
+		{"escaped password", "http://alice:p%40ss%3Aword@" + host + "/", nil,
+			auth{"alice", "p@ss:word", true}, "http://alice:xxxxx@" + host + "/"},
+		{"user only", "http://bob@" + host + "/", nil, auth{"bob", "", true}, "http://bob@" + host + "/"},
+		{"stripped", "http://alice:s3cret@" + host + "/", []Option{WithStripCredentials()},
+			auth{"alice", "s3cret", true}, "http://" + host + "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewSimpleScraper(5*time.Second, tt.opts...).ScrapeResult(context.Background(), tt.url)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if a := <-got; a != tt.want {
+				t.Errorf("server got %+v, want %+v", a, tt.want)
+			}
+			if result.URL != tt.reported {
+				t.Errorf("Result.URL = %s, want %s", result.URL, tt.reported)
+			}
+		})
+	}
+}
+
+func TestMaxHeaderBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Padding", strings.Repeat("x", 4096))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"roomy", []Option{WithMaxHeaderBytes(8192)}, false},
+		{"too small", []Option{WithMaxHeaderBytes(1024)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewSimpleScraper(5*time.Second, tt.opts...).ScrapeResult(context.Background(), srv.URL)
+			if (result.Err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", result.Err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCancellationCause(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/page":
+			w.Write([]byte("0123456789"))
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	errShutdown := errors.New("shutting down")
+	shutdown, cancel := context.WithCancelCause(context.Background())
+	cancel(errShutdown)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		opts    []ConcurrentOption
+		first   string
+		wantErr error
+	}{
+		{"caller cause", shutdown, nil, "/page", errShutdown},
+		{"fail fast", context.Background(), []ConcurrentOption{WithFailFast()}, "/fail", ErrFailFast},
+		{"byte budget", context.Background(), []ConcurrentOption{WithMaxTotalBytes(1)}, "/page", ErrByteBudgetExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 1, tt.opts...)
+			results := c.Scrape(tt.ctx, []string{srv.URL + tt.first, srv.URL + "/slow", srv.URL + "/queued"})
+			if len(results) != 3 {
+				t.Fatalf("got %d results, want 3", len(results))
+			}
+			for _, result := range results {
+				if result.URL == srv.URL+tt.first && tt.wantErr != errShutdown {
+					// the url that stopped the batch keeps its own outcome
+					if errors.Is(result.Err, tt.wantErr) {
+						t.Errorf("%s: got %v, it stopped the batch itself", result.URL, result.Err)
+					}
+					continue
+				}
+				if !errors.Is(result.Err, tt.wantErr) || !errors.Is(result.Err, context.Canceled) {
+					t.Errorf("%s: got error %v, want %v and context.Canceled", result.URL, result.Err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxTotalBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		budget      int64
+		wantOK      int
+		wantStopped int
+	}{
+		{"no limit", 0, 5, 0},
+		{"above the total", 500, 5, 0},
+		// the url that passes the budget is kept, the rest are stopped
+		{"limited", 250, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var urls []string
+			for i := range 5 {
+				urls = append(urls, srv.URL+"/"+strconv.Itoa(i))
+			}
+			c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 1, WithMaxTotalBytes(tt.budget))
+			results, err := c.ScrapeWithError(context.Background(), urls)
+
+			ok, stopped := 0, 0
+			for _, result := range results {
+				switch {
+				case result.Err == nil:
+					ok++
+				case errors.Is(result.Err, ErrByteBudgetExceeded):
+					stopped++
+				default:
+					t.Errorf("%s: unexpected error %v", result.URL, result.Err)
+				}
+			}
+			if ok != tt.wantOK || stopped != tt.wantStopped {
+				t.Errorf("%d fetched and %d stopped, want %d and %d", ok, stopped, tt.wantOK, tt.wantStopped)
+			}
+			if (tt.wantStopped > 0) != errors.Is(err, ErrByteBudgetExceeded) {
+				t.Errorf("ScrapeWithError() error = %v", err)
+			}
+		})
+	}
+}
+
+// panicScraper panics on urls ending in /boom
+type panicScraper struct{}
+
+func (panicScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	if strings.HasSuffix(url, "/boom") {
+		panic("boom")
+	}
+	return []byte("page"), nil
+}
+
+func TestPanicRecovery(t *testing.T) {
+	tests := []struct {
+		name     string
+		urls     []string
+		discover func(Result) []string
+		wantOK   int
+	}{
+		{"scraper", []string{"https://a.com/", "https://a.com/boom", "https://b.com/"}, nil, 2},
+		// the page was fetched before Discover panicked, it keeps its result
+		{"discover", []string{"https://a.com/"}, func(Result) []string { panic("boom") }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []ConcurrentOption
+			if tt.discover != nil {
+				opts = append(opts, WithDiscover(tt.discover, 0))
+			}
+			c := NewConcurrentScraper(panicScraper{}, 2, opts...)
+			results, err := c.ScrapeWithError(context.Background(), tt.urls)
+			if len(results) != len(tt.urls) {
+				t.Fatalf("got %d results, want one per url", len(results))
+			}
+
+			ok := 0
+			for _, result := range results {
+				var panicErr *PanicError
+				switch {
+				case result.Err == nil:
+					ok++
+				case errors.As(result.Err, &panicErr):
+					if panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+						t.Errorf("%s: PanicError %v without its value or stack", result.URL, panicErr)
+					}
+				default:
+					t.Errorf("%s: unexpected error %v", result.URL, result.Err)
+				}
+			}
+			if ok != tt.wantOK {
+				t.Errorf("%d urls succeeded, want %d", ok, tt.wantOK)
+			}
+			// a panic outside the scraper is a bug in the pool and is reported as such
+			var panicErr *PanicError
+			if got := errors.As(err, &panicErr); got != (tt.discover != nil) {
+				t.Errorf("ScrapeWithError() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestWithoutPanicRecovery(t *testing.T) {
+	c := NewConcurrentScraper(panicScraper{}, 1, WithoutPanicRecovery())
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("recovered %v, want the scraper's panic", v)
+		}
+	}()
+	c.recoverScrape(context.Background(), "https://a.com/boom")
+	t.Error("the panic was recovered")
+}
+
+func TestDiscover(t *testing.T) {
+	srv := treeServer(t)
+	links := func(result Result) []string {
+		urls, _ := ExtractLinks(result.URL, result.Data)
+		// every page also links back to the root, which is never fetched twice
+		return append(urls, srv.URL+"/0")
+	}
+
+	tests := []struct {
+		name     string
+		maxPages int
+	}{
+		{"max pages", 7},
+		{"one", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 3, WithDiscover(links, tt.maxPages))
+			results := c.Scrape(context.Background(), []string{srv.URL + "/0"})
+			if len(results) != tt.maxPages {
+				t.Fatalf("got %d results, want %d", len(results), tt.maxPages)
+			}
+			seen := map[string]bool{}
+			for _, result := range results {
+				if result.Err != nil {
+					t.Errorf("%s: %v", result.URL, result.Err)
+				}
+				if seen[result.URL] {
+					t.Errorf("%s was scraped twice", result.URL)
+				}
+				seen[result.URL] = true
+			}
+		})
+	}
+}
+
+// failingStore fails every save
+type failingStore struct{ err error }
+
+func (s failingStore) Save(ctx context.Context, result Result) error { return s.err }
+
+func TestScrapeWithErrorPartialResults(t *testing.T) {
+	var fetched atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the batch is shut down while the third url is fetched
+		if fetched.Add(1) == 3 {
+			cancel()
+		}
+	}))
+	defer srv.Close()
+	errDisk := errors.New("disk full")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		opts      []ConcurrentOption
+		wantOK    int
+		wantErr   error
+		wantEarly bool
+	}{
+		{"shut down", ctx, nil, 2, context.Canceled, true},
+		{"store fails", context.Background(), []ConcurrentOption{WithResultStore(failingStore{errDisk})}, 5, errDisk, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetched.Store(0)
+			var urls []string
+			for i := range 5 {
+				urls = append(urls, srv.URL+"/"+strconv.Itoa(i))
+			}
+			c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 1, tt.opts...)
+			results, err := c.ScrapeWithError(tt.ctx, urls)
+
+			// every url has a result, the finished ones keep theirs
+			if len(results) != len(urls) {
+				t.Fatalf("got %d results, want %d", len(results), len(urls))
+			}
+			ok := 0
+			for _, result := range results {
+				if result.Err == nil {
+					ok++
+				}
+			}
+			if ok != tt.wantOK {
+				t.Errorf("%d urls succeeded, want %d", ok, tt.wantOK)
+			}
+			if !errors.Is(err, tt.wantErr) || strings.Contains(fmt.Sprint(err), "stopped early") != tt.wantEarly {
+				t.Errorf("ScrapeWithError() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxInflight(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		inflight int
+		wantPeak int
+	}{
+		{"workers cap requests", 2, 0, 2},
+		{"more requests than workers", 2, 6, 6},
+		{"fewer requests than workers", 6, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak atomic.Int32
+			release := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				<-release
+			}))
+			defer srv.Close()
+
+			var urls []string
+			for i := range 12 {
+				urls = append(urls, srv.URL+"/"+strconv.Itoa(i))
+			}
+			c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), tt.workers, WithMaxInflight(tt.inflight))
+			done := make(chan []Result)
+			go func() { done <- c.Scrape(context.Background(), urls) }()
+
+			// the requests hold until as many as allowed are running
+			waitFor(t, func() bool { return running.Load() == int32(tt.wantPeak) })
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			for _, result := range <-done {
+				if result.Err != nil {
+					t.Errorf("%s: %v", result.URL, result.Err)
+				}
+			}
+			if p := peak.Load(); p != int32(tt.wantPeak) {
+				t.Errorf("%d requests ran at once, want %d", p, tt.wantPeak)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package learning
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+)
+
+// ErrLoginFailed is returned by Login when the site didn't accept the credentials
+var ErrLoginFailed = errors.New("login failed")
+
+// WithCookieJar keeps the cookies of every response in jar and sends them along
+// with later requests
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(s *SimpleScraper) {
+		s.Client.Jar = jar
+	}
+}
+
+// WithSession keeps cookies between requests in a fresh in-memory jar, see NewSession
+func WithSession() Option {
+	return func(s *SimpleScraper) {
+		s.Client.Jar = newCookieJar()
+	}
+}
+
+// NewSession replaces the cookie jar with an empty one, so a new run starts
+// without the cookies of the previous one. Call it between runs, not while
+// requests are going.
+func (s *SimpleScraper) NewSession() {
+	s.Client.Jar = newCookieJar()
+}
+
+func newCookieJar() http.CookieJar {
+	// cookiejar.New never fails
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
+
+// Login fetches the page at formURL, fills its login form with credentials and
+// submits it. The form's other fields, such as hidden csrf tokens, are sent as
+// they are. The session cookies stay in the scraper's jar, which is created if
+// the scraper has none, so later requests are logged in.
+func (s *SimpleScraper) Login(ctx context.Context, formURL string, credentials neturl.Values) (Result, error) {
+	if s.Client.Jar == nil {
+		s.NewSession()
+	}
+
+	page := s.ScrapeResult(ctx, formURL)
+	if page.Err != nil {
+		return page, fmt.Errorf("failed to load login page: %w", page.Err)
+	}
+	doc, err := html.Parse(bytes.NewReader(page.Data))
+	if err != nil {
+		return page, fmt.Errorf("failed to parse login page: %w", err)
+	}
+	form := findLoginForm(doc)
+	if form == nil {
+		return page, fmt.Errorf("%w: no form on %s", ErrLoginFailed, page.URL)
+	}
+
+	base, err := neturl.Parse(formURL)
+	if err != nil {
+		return page, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if n := len(page.Redirects); n > 0 {
+		if last, err := neturl.Parse(page.Redirects[n-1]); err == nil {
+			base = last
+		}
+	}
+	action, err := base.Parse(attr(form, "action"))
+	if err != nil {
+		return page, fmt.Errorf("failed to parse form action: %w", err)
+	}
+
+	values := formValues(form)
+	for key, vals := range credentials {
+		values[key] = vals
+	}
+	sr := FormRequest(action.String(), values)
+	if strings.EqualFold(attr(form, "method"), http.MethodGet) {
+		action.RawQuery = values.Encode()
+		sr = ScrapeRequest{URL: action.String()}
+	}
+
+	result := s.ScrapeWith(ctx, sr)
+	if result.Err != nil {
+		return result, fmt.Errorf("failed to submit login form: %w", result.Err)
+	}
+	if doc, err := html.Parse(bytes.NewReader(result.Data)); err == nil && hasPasswordInput(doc) {
+		return result, fmt.Errorf("%w: the login form came back", ErrLoginFailed)
+	}
+	if len(s.Client.Jar.Cookies(action)) == 0 {
+		return result, fmt.Errorf("%w: no session cookie was set", ErrLoginFailed)
+	}
+	return result, nil
+}
+
+// findLoginForm returns the first form with a password field, or else the first form
+func findLoginForm(doc *html.Node) *html.Node {
+	var first, login *html.Node
+	walkHTML(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.Data != "form" {
+			return true
+		}
+		if first == nil {
+			first = n
+		}
+		if hasPasswordInput(n) {
+			login = n
+			return false
+		}
+		return true
+	})
+	if login != nil {
+		return login
+	}
+	return first
+}
+
+func hasPasswordInput(n *html.Node) bool {
+	found := false
+	walkHTML(n, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "input" && strings.EqualFold(attr(n, "type"), "password") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// formValues collects the values a browser would submit for the form's inputs,
+// leaving out buttons and unchecked boxes
+func formValues(form *html.Node) neturl.Values {
+	values := neturl.Values{}
+	walkHTML(form, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.Data != "input" {
+			return true
+		}
+		name := attr(n, "name")
+		if name == "" {
+			return true
+		}
+		switch strings.ToLower(attr(n, "type")) {
+		case "submit", "button", "reset", "image", "file":
+			return true
+		case "checkbox", "radio":
+			if _, checked := attrOK(n, "checked"); !checked {
+				return true
+			}
+			if _, ok := attrOK(n, "value"); !ok {
+				values.Add(name, "on")
+				return true
+			}
+		}
+		values.Add(name, attr(n, "value"))
+		return true
+	})
+	return values
+}
+
+// walkHTML calls visit for n and its descendants in document order until visit returns false
+func walkHTML(n *html.Node, visit func(*html.Node) bool) bool {
+	if !visit(n) {
+		return false
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if !walkHTML(child, visit) {
+			return false
+		}
+	}
+	return true
+}
+
+func attr(n *html.Node, key string) string {
+	val, _ := attrOK(n, key)
+	return val
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
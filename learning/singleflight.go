@@ -0,0 +1,95 @@
+package learning
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// SingleflightScraper wraps a Scraper and coalesces concurrent scrapes of the same
+// url into one request, every caller gets its own copy of the body
+type SingleflightScraper struct {
+	Scraper Scraper
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a scrape shared by the callers waiting for it
+type flight struct {
+	done    chan struct{}
+	data    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// NewSingleflightScraper creates a SingleflightScraper
+func NewSingleflightScraper(scraper Scraper) *SingleflightScraper {
+	return &SingleflightScraper{Scraper: scraper}
+}
+
+// Name identifies the SingleflightScraper and the scraper it wraps
+func (s *SingleflightScraper) Name() string {
+	return "singleflight(" + ScraperName(s.Scraper) + ")"
+}
+
+// Scrape fetches url, joining a request for the same url that is already in
+// flight. A caller that gives up only stops waiting, the shared request goes on
+// for the others and is cancelled once the last caller is gone.
+func (s *SingleflightScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	s.mu.Lock()
+	f := s.flights[url]
+	if f == nil {
+		f = s.start(ctx, url)
+	}
+	f.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return bytes.Clone(f.data), f.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		if f.waiters--; f.waiters == 0 {
+			f.cancel()
+			s.forget(url, f)
+		}
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// start runs the shared scrape of url, s.mu must be held. The scrape keeps the
+// values of ctx but not its cancellation, which belongs to the first caller only.
+func (s *SingleflightScraper) start(ctx context.Context, url string) *flight {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	f := &flight{done: make(chan struct{}), cancel: cancel}
+	if s.flights == nil {
+		s.flights = map[string]*flight{}
+	}
+	s.flights[url] = f
+
+	go func() {
+		defer cancel()
+		f.data, f.err = s.Scraper.Scrape(ctx, url)
+
+		s.mu.Lock()
+		s.forget(url, f)
+		s.mu.Unlock()
+		close(f.done)
+	}()
+	return f
+}
+
+// forget removes f so later callers start a new scrape, s.mu must be held
+func (s *SingleflightScraper) forget(url string, f *flight) {
+	if s.flights[url] == f {
+		delete(s.flights, url)
+	}
+}
+
+// Close closes the wrapped scraper
+func (s *SingleflightScraper) Close() error {
+	return closeScraper(s.Scraper)
+}
@@ -0,0 +1,109 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateScraper blocks every scrape until release is closed or ctx is done
+type gateScraper struct {
+	release   chan struct{}
+	calls     atomic.Int32
+	cancelled chan struct{}
+}
+
+func (g *gateScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.release:
+		return []byte("page"), nil
+	case <-ctx.Done():
+		close(g.cancelled)
+		return nil, ctx.Err()
+	}
+}
+
+func TestSingleflightScraper(t *testing.T) {
+	tests := []struct {
+		name string
+		// cancel lists which of the two callers give up before the scrape is done
+		cancel        [2]bool
+		wantCancelled bool
+	}{
+		{"both wait", [2]bool{false, false}, false},
+		{"one leaves", [2]bool{true, false}, false},
+		{"both leave", [2]bool{true, true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &gateScraper{release: make(chan struct{}), cancelled: make(chan struct{})}
+			s := NewSingleflightScraper(g)
+
+			type scrape struct {
+				data []byte
+				err  error
+			}
+			var cancels [2]context.CancelFunc
+			var results [2]chan scrape
+			for i := range results {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				cancels[i], results[i] = cancel, make(chan scrape, 1)
+				go func() {
+					data, err := s.Scrape(ctx, "https://example.com/")
+					results[i] <- scrape{data, err}
+				}()
+			}
+			waitFor(t, func() bool {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				f := s.flights["https://example.com/"]
+				return f != nil && f.waiters == 2
+			})
+
+			for i, cancel := range tt.cancel {
+				if cancel {
+					cancels[i]()
+					if res := <-results[i]; !errors.Is(res.err, context.Canceled) {
+						t.Errorf("caller %d got %q, %v, want context.Canceled", i, res.data, res.err)
+					}
+				}
+			}
+			if tt.wantCancelled {
+				select {
+				case <-g.cancelled:
+				case <-time.After(5 * time.Second):
+					t.Fatal("the shared scrape wasn't cancelled after every caller left")
+				}
+				return
+			}
+
+			close(g.release)
+			for i, cancel := range tt.cancel {
+				if !cancel {
+					if res := <-results[i]; res.err != nil || string(res.data) != "page" {
+						t.Errorf("caller %d got %q, %v, want the page", i, res.data, res.err)
+					}
+				}
+			}
+			if n := g.calls.Load(); n != 1 {
+				t.Errorf("scraped %d times, want once", n)
+			}
+		})
+	}
+}
+
+// waitFor polls cond until it holds or a few seconds pass
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition never held")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,145 @@
+package learning
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSitemapDepth limits how deep sitemap indexes may point to other indexes
+const maxSitemapDepth = 3
+
+// maxSitemapBytes is the largest sitemap the protocol allows once unpacked, a
+// gzipped sitemap that unpacks to more fails with ErrTooLarge
+const maxSitemapBytes = 50 << 20
+
+// SitemapEntry is a single url listed in a sitemap
+type SitemapEntry struct {
+	Loc     string
+	LastMod time.Time
+	// Priority is between 0 and 1, sitemaps default it to 0.5
+	Priority float64
+}
+
+// sitemapDoc matches both a urlset and a sitemapindex document
+type sitemapDoc struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc      string `xml:"loc"`
+		LastMod  string `xml:"lastmod"`
+		Priority string `xml:"priority"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// DiscoverSitemap fetches /sitemap.xml of the site siteURL belongs to
+func DiscoverSitemap(ctx context.Context, scraper Scraper, siteURL string) ([]SitemapEntry, error) {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	return FetchSitemap(ctx, scraper, u.Scheme+"://"+u.Host+"/sitemap.xml")
+}
+
+// FetchSitemap fetches a sitemap and returns its entries, sitemap indexes are
+// followed and gzipped sitemaps are unpacked
+func FetchSitemap(ctx context.Context, scraper Scraper, sitemapURL string) ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	seen := map[string]bool{}
+
+	var fetch func(sitemapURL string, depth int) error
+	fetch = func(sitemapURL string, depth int) error {
+		if seen[sitemapURL] || depth > maxSitemapDepth {
+			return nil
+		}
+		seen[sitemapURL] = true
+
+		data, err := scraper.Scrape(ctx, sitemapURL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch sitemap %s: %w", sitemapURL, err)
+		}
+		doc, err := parseSitemap(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+		}
+
+		for _, u := range doc.URLs {
+			entries = append(entries, sitemapEntry(u.Loc, u.LastMod, u.Priority))
+		}
+		for _, sm := range doc.Sitemaps {
+			if err := fetch(strings.TrimSpace(sm.Loc), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := fetch(sitemapURL, 0); err != nil {
+		return entries, err
+	}
+	return entries, nil
+}
+
+// ScrapeSitemap scrapes every url listed in the sitemap at sitemapURL
+func (c *ConcurrentScraper) ScrapeSitemap(ctx context.Context, sitemapURL string) ([]Result, error) {
+	entries, err := FetchSitemap(ctx, c.Scraper, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	return c.Scrape(ctx, SitemapURLs(entries)), nil
+}
+
+// SitemapURLs returns the locations of entries
+func SitemapURLs(entries []SitemapEntry) []string {
+	urls := make([]string, 0, len(entries))
+	for _, e := range entries {
+		urls = append(urls, e.Loc)
+	}
+	return urls
+}
+
+func parseSitemap(data []byte) (*sitemapDoc, error) {
+	// sitemap.xml.gz files are served as is, not with a Content-Encoding
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(io.LimitReader(zr, maxSitemapBytes+1)); err != nil {
+			return nil, err
+		}
+		if len(data) > maxSitemapBytes {
+			return nil, fmt.Errorf("%w: unpacked sitemap exceeds %d bytes", ErrTooLarge, maxSitemapBytes)
+		}
+	}
+
+	var doc sitemapDoc
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func sitemapEntry(loc, lastMod, priority string) SitemapEntry {
+	entry := SitemapEntry{Loc: strings.TrimSpace(loc), Priority: 0.5}
+	if p, err := strconv.ParseFloat(strings.TrimSpace(priority), 64); err == nil {
+		entry.Priority = p
+	}
+	// lastmod uses the W3C datetime format, which allows several precisions
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(lastMod)); err == nil {
+			entry.LastMod = t
+			break
+		}
+	}
+	return entry
+}
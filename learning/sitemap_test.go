@@ -0,0 +1,50 @@
+package learning
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseSitemapGzip(t *testing.T) {
+	sitemap := `<urlset><url><loc>https://example.com/</loc></url></urlset>`
+	// whitespace is valid xml and unpacks to well past the limit
+	bomb := io.MultiReader(bytes.NewReader([]byte("<urlset>")),
+		io.LimitReader(repeatReader(' '), maxSitemapBytes), bytes.NewReader([]byte("</urlset>")))
+	huge, err := io.ReadAll(bomb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"plain", []byte(sitemap), nil},
+		{"gzip", compress(t, "gzip", []byte(sitemap)), nil},
+		{"gzip bomb", compress(t, "gzip", huge), ErrTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseSitemap(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseSitemap() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (len(doc.URLs) != 1 || doc.URLs[0].Loc != "https://example.com/") {
+				t.Errorf("parsed %+v, want the one url", doc.URLs)
+			}
+		})
+	}
+}
+
+// repeatReader reads b forever
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,138 @@
+package learning
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HostStats holds the numbers collected for a single host
+type HostStats struct {
+	Requests   int
+	Successes  int
+	Failures   int
+	Bytes      int64
+	AvgLatency time.Duration
+}
+
+// Stats summarizes a scraping run
+type Stats struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Bytes     int64
+	// FetchTime is the sum of the durations of all fetches
+	FetchTime time.Duration
+	// Elapsed is the wall-clock time from the first fetch starting to the last one ending
+	Elapsed time.Duration
+	// Errors counts the failures by error class, see ErrorClass
+	Errors map[string]int
+	Hosts  map[string]HostStats
+}
+
+// StatsCollector aggregates results into Stats, it is safe for concurrent use
+type StatsCollector struct {
+	mu      sync.Mutex
+	stats   Stats
+	latency map[string]time.Duration
+	first   time.Time
+	last    time.Time
+}
+
+// NewStatsCollector creates an empty StatsCollector
+func NewStatsCollector() *StatsCollector {
+	return &StatsCollector{
+		stats:   Stats{Errors: map[string]int{}, Hosts: map[string]HostStats{}},
+		latency: map[string]time.Duration{},
+	}
+}
+
+// Add records a single result
+func (c *StatsCollector) Add(result Result) {
+	host := hostOf(result.URL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := c.stats.Hosts[host]
+	h.Requests++
+	c.stats.Total++
+	if result.Err != nil {
+		h.Failures++
+		c.stats.Failed++
+		c.stats.Errors[ErrorClass(result.Err)]++
+	} else {
+		h.Successes++
+		c.stats.Succeeded++
+	}
+	h.Bytes += int64(len(result.Data))
+	c.stats.Bytes += int64(len(result.Data))
+
+	c.stats.FetchTime += result.Duration
+	if !result.Started.IsZero() {
+		if c.first.IsZero() || result.Started.Before(c.first) {
+			c.first = result.Started
+		}
+		if end := result.Started.Add(result.Duration); end.After(c.last) {
+			c.last = end
+		}
+		c.stats.Elapsed = c.last.Sub(c.first)
+	}
+	c.latency[host] += result.Duration
+	h.AvgLatency = c.latency[host] / time.Duration(h.Requests)
+	c.stats.Hosts[host] = h
+}
+
+// Stats returns a copy of the collected stats
+func (c *StatsCollector) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Errors = make(map[string]int, len(c.stats.Errors))
+	for class, n := range c.stats.Errors {
+		stats.Errors[class] = n
+	}
+	stats.Hosts = make(map[string]HostStats, len(c.stats.Hosts))
+	for host, h := range c.stats.Hosts {
+		stats.Hosts[host] = h
+	}
+	return stats
+}
+
+// Reset clears all collected numbers, including the per-host breakdown
+func (c *StatsCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats = Stats{Errors: map[string]int{}, Hosts: map[string]HostStats{}}
+	c.latency = map[string]time.Duration{}
+	c.first, c.last = time.Time{}, time.Time{}
+}
+
+// Parallelism is the average number of fetches that were running at the same time,
+// a value close to 1 means the batch barely used its concurrency
+func (s Stats) Parallelism() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.FetchTime) / float64(s.Elapsed)
+}
+
+// Summarize builds the Stats for a finished batch of results
+func Summarize(results []Result) Stats {
+	c := NewStatsCollector()
+	for _, result := range results {
+		c.Add(result)
+	}
+	return c.Stats()
+}
+
+// hostOf returns the hostname of a url, or an empty string if it can't be parsed
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,85 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHostStats(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("0123456789"))
+	}))
+	defer fast.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("abcde"))
+	}))
+	defer slow.Close()
+	// both servers listen on 127.0.0.1, reaching one through localhost makes two hosts
+	slowURL := strings.Replace(slow.URL, "127.0.0.1", "localhost", 1)
+
+	urls := []string{fast.URL + "/a", fast.URL + "/b", fast.URL + "/missing", slowURL + "/a", slowURL + "/b"}
+	collector := NewStatsCollector()
+	for result := range NewConcurrentScraper(NewSimpleScraper(5*time.Second), 3).ScrapeStream(context.Background(), urls) {
+		collector.Add(result)
+	}
+	stats := collector.Stats()
+
+	want := map[string]HostStats{
+		"127.0.0.1": {Requests: 3, Successes: 2, Failures: 1, Bytes: 20},
+		"localhost": {Requests: 2, Successes: 2, Bytes: 10},
+	}
+	if len(stats.Hosts) != len(want) {
+		t.Fatalf("got stats for %d hosts, want %d: %v", len(stats.Hosts), len(want), stats.Hosts)
+	}
+	for host, w := range want {
+		got := stats.Hosts[host]
+		if got.Requests != w.Requests || got.Successes != w.Successes || got.Failures != w.Failures || got.Bytes != w.Bytes {
+			t.Errorf("%s: got %+v, want %+v", host, got, w)
+		}
+	}
+	if fast, slow := stats.Hosts["127.0.0.1"].AvgLatency, stats.Hosts["localhost"].AvgLatency; slow < 20*time.Millisecond || fast >= slow {
+		t.Errorf("average latency %v for the fast host and %v for the slow one", fast, slow)
+	}
+	if stats.Total != 5 || stats.Failed != 1 || stats.Errors["status"] != 1 {
+		t.Errorf("got totals %+v", stats)
+	}
+}
+
+func TestSummarizeTiming(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset, d time.Duration) Result {
+		return Result{URL: "https://example.com/", Started: start.Add(offset), Duration: d}
+	}
+	tests := []struct {
+		name            string
+		results         []Result
+		wantFetch       time.Duration
+		wantElapsed     time.Duration
+		wantParallelism float64
+	}{
+		{"empty", nil, 0, 0, 0},
+		{"sequential", []Result{at(0, time.Second), at(time.Second, time.Second)}, 2 * time.Second, 2 * time.Second, 1},
+		{"overlapping", []Result{at(0, 2*time.Second), at(0, 2*time.Second), at(time.Second, time.Second)},
+			5 * time.Second, 2 * time.Second, 2.5},
+		// results without a start time count towards FetchTime only
+		{"no start", []Result{{URL: "https://example.com/", Duration: time.Second}}, time.Second, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := Summarize(tt.results)
+			if stats.FetchTime != tt.wantFetch || stats.Elapsed != tt.wantElapsed || stats.Parallelism() != tt.wantParallelism {
+				t.Errorf("got fetch time %v, elapsed %v, parallelism %v, want %v, %v, %v",
+					stats.FetchTime, stats.Elapsed, stats.Parallelism(), tt.wantFetch, tt.wantElapsed, tt.wantParallelism)
+			}
+		})
+	}
+}
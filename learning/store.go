@@ -0,0 +1,130 @@
+package learning
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ResultStore persists results
+type ResultStore interface {
+	Save(ctx context.Context, result Result) error
+}
+
+// WithResultStore saves every result of a batch to store as soon as it is done,
+// a failed save is reported in the error of ScrapeWithError
+func WithResultStore(store ResultStore) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Store = store
+	}
+}
+
+// storedResult is the metadata of a Result as FileStore writes it
+type storedResult struct {
+	URL        string        `json:"url"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Started    time.Time     `json:"started"`
+	Duration   time.Duration `json:"duration"`
+	Header     http.Header   `json:"header,omitempty"`
+	// File is the name of the body file, empty for failed results
+	File string `json:"file,omitempty"`
+}
+
+// FileStore saves the body of every result to its own file in Dir and its
+// metadata next to it in a .json file. Naming defaults to SafeName.
+type FileStore struct {
+	Dir    string
+	Naming FileNaming
+}
+
+// NewFileStore creates a FileStore in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+// Save writes result to the store
+func (f *FileStore) Save(ctx context.Context, result Result) error {
+	naming := f.Naming
+	if naming == nil {
+		naming = SafeName
+	}
+	name := naming(result.URL)
+	if err := validFileName(name); err != nil {
+		return fmt.Errorf("unsafe file name for %s: %w", result.URL, err)
+	}
+
+	meta := storedResult{
+		URL:        result.URL,
+		StatusCode: result.StatusCode,
+		Started:    result.Started,
+		Duration:   result.Duration,
+		Header:     result.Header,
+	}
+	if result.Err != nil {
+		meta.Error = result.Err.Error()
+	} else {
+		meta.File = name
+		if err := os.WriteFile(filepath.Join(f.Dir, name), result.Data, 0o644); err != nil {
+			return fmt.Errorf("failed to save %s: %w", result.URL, err)
+		}
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata of %s: %w", result.URL, err)
+	}
+	if err := os.WriteFile(filepath.Join(f.Dir, name+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("failed to save metadata of %s: %w", result.URL, err)
+	}
+	return nil
+}
+
+// SQLiteStore saves results to the results table of a SQLite database. It works
+// on a *sql.DB so the caller picks the driver, for example modernc.org/sqlite or
+// github.com/mattn/go-sqlite3, by importing it.
+type SQLiteStore struct {
+	DB *sql.DB
+}
+
+// NewSQLiteStore creates a SQLiteStore and the results table if it doesn't exist
+func NewSQLiteStore(ctx context.Context, db *sql.DB) (*SQLiteStore, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		status_code INTEGER NOT NULL,
+		error TEXT,
+		data BLOB,
+		started TEXT NOT NULL,
+		duration_ms INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create results table: %w", err)
+	}
+	return &SQLiteStore{DB: db}, nil
+}
+
+// Save inserts result as a new row, scraping a url again adds another row
+func (s *SQLiteStore) Save(ctx context.Context, result Result) error {
+	var errText sql.NullString
+	if result.Err != nil {
+		errText = sql.NullString{String: result.Err.Error(), Valid: true}
+	}
+
+	_, err := s.DB.ExecContext(ctx,
+		`INSERT INTO results (url, status_code, error, data, started, duration_ms) VALUES (?, ?, ?, ?, ?, ?)`,
+		result.URL, result.StatusCode, errText, result.Data,
+		result.Started.UTC().Format(time.RFC3339Nano), result.Duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", result.URL, err)
+	}
+	return nil
+}
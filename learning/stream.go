@@ -0,0 +1,96 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// StreamScraper is implemented by scrapers that can hand out the body as it is
+// read instead of loading it into memory
+type StreamScraper interface {
+	ScrapeStream(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// ResultStreamScraper is implemented by stream scrapers that can report the
+// response metadata next to the body, streaming prefers it over ScrapeStream
+type ResultStreamScraper interface {
+	ScrapeStreamResult(ctx context.Context, url string) (Result, io.ReadCloser)
+}
+
+// ScrapeStream fetches url and returns the unread body, the caller must close it.
+// Retries only apply until the response arrives.
+func (s *SimpleScraper) ScrapeStream(ctx context.Context, url string) (io.ReadCloser, error) {
+	result, body := s.ScrapeStreamResult(ctx, url)
+	return body, result.Err
+}
+
+// ScrapeStreamResult works like ScrapeStream and also returns the response
+// metadata. The body is nil when the result has an error.
+func (s *SimpleScraper) ScrapeStreamResult(ctx context.Context, url string) (Result, io.ReadCloser) {
+	var resp *http.Response
+	result := s.withRetries(ctx, ScrapeRequest{URL: url}, func(result *Result) {
+		resp, result.Err = s.do(ctx, ScrapeRequest{URL: url}, result)
+	})
+	if result.Err != nil {
+		return result, nil
+	}
+	if result.NotModified {
+		resp.Body.Close()
+		result.Err = fmt.Errorf("%w: %s", ErrNotModified, result.URL)
+		return result, nil
+	}
+	return result, resp.Body
+}
+
+// BodyConsumer processes a streamed body, it must not keep body after returning
+type BodyConsumer func(url string, body io.Reader) error
+
+// WithStreamConsumer streams every body to consume instead of keeping it in
+// Result.Data, for scrapers that implement StreamScraper
+func WithStreamConsumer(consume BodyConsumer) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Consumer = consume
+	}
+}
+
+// streamOne streams url to consume, the result has no Data but counts the
+// streamed bytes
+func streamOne(ctx context.Context, scraper StreamScraper, url string, consume BodyConsumer) Result {
+	start := time.Now()
+	var result Result
+	var body io.ReadCloser
+	if rs, ok := scraper.(ResultStreamScraper); ok {
+		result, body = rs.ScrapeStreamResult(ctx, url)
+	} else {
+		result.URL = url
+		body, result.Err = scraper.ScrapeStream(ctx, url)
+	}
+
+	if result.Err == nil {
+		counted := &countingReader{r: body}
+		if err := consume(url, counted); err != nil {
+			result.Err = fmt.Errorf("failed to consume body: %w", err)
+		}
+		body.Close()
+		result.Streamed = counted.n
+	}
+
+	result.Started = start
+	result.Duration = time.Since(start)
+	return result
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,131 @@
+package learning
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScrapeStreamRetries(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		io.WriteString(w, strings.Repeat("x", 1000))
+	}))
+	defer srv.Close()
+
+	s := NewSimpleScraper(5*time.Second, WithRetry(RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond}))
+	result, body := s.ScrapeStreamResult(context.Background(), srv.URL)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	defer body.Close()
+	if result.StatusCode != http.StatusOK || result.Attempts != 2 {
+		t.Errorf("got status %d after %d attempts, want 200 after 2", result.StatusCode, result.Attempts)
+	}
+}
+
+func TestStreamCountsBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 1000))
+	}))
+	defer srv.Close()
+
+	consume := func(url string, body io.Reader) error {
+		_, err := io.Copy(io.Discard, body)
+		return err
+	}
+	c := NewConcurrentScraper(NewSimpleScraper(5*time.Second), 1,
+		WithStreamConsumer(consume), WithMaxTotalBytes(1500))
+	results := c.Scrape(context.Background(), []string{srv.URL + "/1", srv.URL + "/2", srv.URL + "/3"})
+
+	var streamed int64
+	failed := 0
+	for _, result := range results {
+		streamed += result.Streamed
+		if result.Err != nil {
+			failed++
+		} else if result.StatusCode != http.StatusOK {
+			t.Errorf("%s: status %d, want 200", result.URL, result.StatusCode)
+		}
+	}
+	// the second url passes the budget, the third is never fetched
+	if streamed != 2000 || failed != 1 {
+		t.Errorf("streamed %d bytes with %d failures, want 2000 and 1", streamed, failed)
+	}
+}
+
+// syntheticScraper makes up a fresh body of size bytes for every url
+type syntheticScraper struct {
+	size int
+}
+
+func (s syntheticScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	return make([]byte, s.size), nil
+}
+
+func syntheticURLs(n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = "https://example.com/" + strconv.Itoa(i)
+	}
+	return urls
+}
+
+func heapInUse() int64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapInuse)
+}
+
+func TestScrapeEachFlatMemory(t *testing.T) {
+	const pages, size = 20000, 16 << 10
+	c := NewConcurrentScraper(syntheticScraper{size: size}, 8)
+
+	var done atomic.Int64
+	var early, late atomic.Int64
+	err := c.ScrapeEach(context.Background(), syntheticURLs(pages), func(r Result) {
+		if r.Err != nil || len(r.Data) != size {
+			t.Errorf("%s: got %d bytes and %v", r.URL, len(r.Data), r.Err)
+		}
+		switch done.Add(1) {
+		case pages / 10:
+			early.Store(heapInUse())
+		case pages - pages/10:
+			late.Store(heapInUse())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Load() != pages {
+		t.Fatalf("handled %d results, want %d", done.Load(), pages)
+	}
+
+	// 16000 bodies of 16KiB went by in between, keeping them would take 250MiB,
+	// only the urls remembered by the Deduper may add up
+	if grown := late.Load() - early.Load(); grown > 16<<20 {
+		t.Errorf("heap grew by %d bytes over the crawl, want it flat", grown)
+	}
+}
+
+func BenchmarkScrapeEach(b *testing.B) {
+	c := NewConcurrentScraper(syntheticScraper{size: 16 << 10}, 8)
+	urls := syntheticURLs(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		c.ScrapeEach(context.Background(), urls, func(Result) {})
+	}
+}
@@ -0,0 +1,35 @@
+package learning
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// StubScraper implements the Scraper interface with canned responses, unknown
+// urls fail with ErrNotFound
+type StubScraper struct {
+	Pages map[string][]byte
+}
+
+// NewStubScraper creates a StubScraper serving pages
+func NewStubScraper(pages map[string][]byte) *StubScraper {
+	return &StubScraper{Pages: pages}
+}
+
+// Name identifies the StubScraper
+func (s *StubScraper) Name() string {
+	return "stub"
+}
+
+// Scrape returns a copy of the canned page for url
+func (s *StubScraper) Scrape(ctx context.Context, url string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	page, ok := s.Pages[url]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, url)
+	}
+	return bytes.Clone(page), nil
+}
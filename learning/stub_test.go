@@ -0,0 +1,40 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStubScraper(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewStubScraper(map[string][]byte{"https://example.com/": []byte("page")})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		url     string
+		want    string
+		wantErr error
+	}{
+		{"known", context.Background(), "https://example.com/", "page", nil},
+		{"unknown", context.Background(), "https://example.com/other", "", ErrNotFound},
+		{"cancelled", cancelled, "https://example.com/", "", context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := s.Scrape(tt.ctx, tt.url)
+			if string(data) != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Scrape() = %q, %v, want %q, %v", data, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	// callers get a copy they may change
+	data, _ := s.Scrape(context.Background(), "https://example.com/")
+	data[0] = 'P'
+	if string(s.Pages["https://example.com/"]) != "page" {
+		t.Errorf("changing the result changed the canned page to %q", s.Pages["https://example.com/"])
+	}
+}
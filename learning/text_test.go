@@ -0,0 +1,30 @@
+package learning
+
+import "testing"
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"blocks", "<p>one</p><p>two <b>three</b></p>", "one\ntwo three"},
+		{"head", "<html><head><title>T</title><style>p{}</style></head><body>text</body></html>", "text"},
+		// </head> and <body> are optional in html
+		{"implied body", "<html><head><title>T</title><p>body text", "body text"},
+		{"script", "<div>a<script>var x = '<p>'</script>b</div>", "a b"},
+		{"entities", "<p>fish &amp; chips</p>", "fish & chips"},
+		{"whitespace", "<p>  lots \n of\t space </p>", "lots of space"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractText([]byte(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
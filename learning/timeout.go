@@ -0,0 +1,41 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type timeoutKey struct{}
+
+// WithRequestTimeout returns a context that gives the requests of a SimpleScraper
+// made with it timeout d instead of the timeout of the scraper's client.
+// Unlike context.WithTimeout it can also grant more time than the client allows,
+// and it applies to each attempt rather than to all retries together.
+func WithRequestTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, d)
+}
+
+// WithURLTimeouts gives every url of a batch the timeout timeout returns for it,
+// zero keeps the client's timeout
+func WithURLTimeouts(timeout func(url string) time.Duration) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Timeout = timeout
+	}
+}
+
+// client returns the http client for a request, a copy of s.Client when the
+// request has its own timeout
+func (s *SimpleScraper) client(ctx context.Context, sr ScrapeRequest) *http.Client {
+	timeout := sr.Timeout
+	if timeout <= 0 {
+		timeout, _ = ctx.Value(timeoutKey{}).(time.Duration)
+	}
+	if timeout <= 0 {
+		return s.Client
+	}
+
+	client := *s.Client
+	client.Timeout = timeout
+	return &client
+}
@@ -0,0 +1,73 @@
+package learning
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// WithTLSConfig replaces the TLS configuration of the transport with a copy of
+// config, the other TLS options change that copy when they come after it
+func WithTLSConfig(config *tls.Config) Option {
+	return func(s *SimpleScraper) {
+		s.transport.TLSClientConfig = config.Clone()
+	}
+}
+
+// WithRootCAs verifies servers against pool instead of the system roots, for
+// targets signed by a private CA. See LoadCertPool.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(s *SimpleScraper) {
+		s.tlsConfig().RootCAs = pool
+	}
+}
+
+// WithClientCertificate presents cert to servers that ask for one
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(s *SimpleScraper) {
+		config := s.tlsConfig()
+		config.Certificates = append(config.Certificates, cert)
+	}
+}
+
+// WithMinTLSVersion refuses servers that only speak versions below v, such as tls.VersionTLS12
+func WithMinTLSVersion(v uint16) Option {
+	return func(s *SimpleScraper) {
+		s.tlsConfig().MinVersion = v
+	}
+}
+
+// WithInsecureSkipVerify accepts any server certificate. It is meant for lab
+// targets with self-signed certificates, never use it against the internet.
+func WithInsecureSkipVerify() Option {
+	return func(s *SimpleScraper) {
+		s.tlsConfig().InsecureSkipVerify = true
+	}
+}
+
+// tlsConfig returns the TLS configuration of the transport, creating it if needed
+func (s *SimpleScraper) tlsConfig() *tls.Config {
+	if s.transport.TLSClientConfig == nil {
+		s.transport.TLSClientConfig = &tls.Config{}
+	}
+	return s.transport.TLSClientConfig
+}
+
+// LoadCertPool returns the system roots plus the PEM encoded certificates in files
+func LoadCertPool(files ...string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificates: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", file)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,63 @@
+package learning
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TraceInfo describes how the connection of a fetch was set up
+type TraceInfo struct {
+	// Reused is true when an idle keep-alive connection was used
+	Reused       bool
+	DNSLookup    time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// FirstByte is the time from sending the request until the first response byte
+	FirstByte time.Duration
+}
+
+// WithTrace records connection details of every fetch in Result.Trace
+func WithTrace() Option {
+	return func(s *SimpleScraper) {
+		s.trace = true
+	}
+}
+
+// withTrace attaches a client trace to req that fills info
+func withTrace(req *http.Request, info *TraceInfo) *http.Request {
+	var dnsStart, connectStart, tlsStart time.Time
+	// the request is written and the response read on different goroutines
+	var mu sync.Mutex
+	var wrote time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(conn httptrace.GotConnInfo) {
+			info.Reused = conn.Reused
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			info.DNSLookup = time.Since(dnsStart)
+		},
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(string, string, error) {
+			info.Connect = time.Since(connectStart)
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			info.TLSHandshake = time.Since(tlsStart)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			wrote = time.Now()
+			mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			info.FirstByte = time.Since(wrote)
+			mu.Unlock()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,62 @@
+package learning
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTraceConnectionReuse(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+	tests := []struct {
+		name    string
+		server  func(http.Handler) *httptest.Server
+		opts    []Option
+		wantTLS bool
+	}{
+		{"http", httptest.NewServer, nil, false},
+		{"https", httptest.NewTLSServer, []Option{WithInsecureSkipVerify()}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := tt.server(handler)
+			defer srv.Close()
+			s := NewSimpleScraper(5*time.Second, append(tt.opts, WithTrace())...)
+			defer s.Close()
+
+			first := s.ScrapeResult(context.Background(), srv.URL)
+			second := s.ScrapeResult(context.Background(), srv.URL)
+			for _, result := range []Result{first, second} {
+				if result.Err != nil {
+					t.Fatal(result.Err)
+				}
+				if result.Trace == nil {
+					t.Fatal("Result.Trace is nil")
+				}
+			}
+
+			if first.Trace.Reused || first.Trace.Connect <= 0 {
+				t.Errorf("first fetch: %+v, want a new connection", *first.Trace)
+			}
+			if (first.Trace.TLSHandshake > 0) != tt.wantTLS {
+				t.Errorf("first fetch took %v for the TLS handshake, want one %v", first.Trace.TLSHandshake, tt.wantTLS)
+			}
+			// the second fetch neither connects nor shakes hands again
+			if !second.Trace.Reused || second.Trace.Connect != 0 || second.Trace.TLSHandshake != 0 {
+				t.Errorf("second fetch: %+v, want the idle connection reused", *second.Trace)
+			}
+			if second.Trace.FirstByte <= 0 {
+				t.Errorf("second fetch: FirstByte = %v, want it measured", second.Trace.FirstByte)
+			}
+		})
+	}
+
+	result := NewSimpleScraper(5*time.Second).ScrapeResult(context.Background(), "http://127.0.0.1:0/")
+	if result.Trace != nil {
+		t.Errorf("Result.Trace = %+v without WithTrace, want nil", result.Trace)
+	}
+}
@@ -0,0 +1,41 @@
+package learning
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans created here
+const tracerName = "test/learning"
+
+// WithTracerProvider creates a span for every ScrapeWith call, and so every
+// Scrape, using tp. The span becomes a child of the span in ctx and its trace
+// context is sent along with the request using the global propagator.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *SimpleScraper) {
+		s.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// WithBatchTracerProvider creates a span for every batch using tp, the spans
+// of the urls in the batch become its children
+func WithBatchTracerProvider(tp trace.TracerProvider) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Tracer = tp.Tracer(tracerName)
+	}
+}
+
+// endScrapeSpan records the outcome of result on span and ends it
+func endScrapeSpan(span trace.Span, result Result) {
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", result.StatusCode),
+		attribute.Int("http.response.body.size", len(result.Data)),
+		attribute.Int("scrape.attempts", result.Attempts),
+	)
+	if result.Err != nil {
+		span.RecordError(result.Err)
+		span.SetStatus(codes.Error, result.Err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,40 @@
+package learning
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashKeyPrefix keeps the hashes apart from pages when the Cache is shared
+// with a CachingScraper
+const hashKeyPrefix = "sha256:"
+
+// WithChangeDetection keeps the sha256 of every fetched body in hashes and flags
+// a result Unchanged when its body hashes the same as on the last crawl. With a
+// DiskCache the hashes survive between runs, which catches unchanged pages even
+// when the server sends no validators for conditional requests.
+func WithChangeDetection(hashes Cache) ConcurrentOption {
+	return func(c *ConcurrentScraper) {
+		c.Hashes = hashes
+	}
+}
+
+// checkUnchanged compares the body of a successful result with the hash of the
+// last crawl and stores its own hash for the next one
+func (c *ConcurrentScraper) checkUnchanged(result *Result) {
+	if c.Hashes == nil || result.Streamed > 0 || result.NotModified {
+		// streamed bodies are gone and 304s have none, there is nothing to hash
+		return
+	}
+
+	sum := sha256.Sum256(result.Data)
+	hash := hex.EncodeToString(sum[:])
+	key := hashKeyPrefix + result.URL
+	if last, ok := c.Hashes.Get(key); ok && string(last.Data) == hash {
+		result.Unchanged = true
+		return
+	}
+	if err := c.Hashes.Set(key, CacheEntry{Data: []byte(hash)}); err != nil {
+		c.logger().Warn("failed to store page hash", "url", result.URL, "error", err)
+	}
+}
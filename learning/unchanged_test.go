@@ -0,0 +1,43 @@
+package learning
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChangeDetection(t *testing.T) {
+	dir := t.TempDir()
+	pages := map[string][]byte{
+		"https://example.com/stable":  []byte("same"),
+		"https://example.com/changes": []byte("before"),
+	}
+
+	crawl := func() map[string]bool {
+		// a new cache on the same directory, like the next run of a crawler
+		hashes, err := NewDiskCache(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := NewConcurrentScraper(NewStubScraper(pages), 2, WithChangeDetection(hashes))
+		unchanged := map[string]bool{}
+		for _, r := range c.Scrape(context.Background(), []string{"https://example.com/stable", "https://example.com/changes"}) {
+			if r.Err != nil {
+				t.Fatal(r.Err)
+			}
+			unchanged[r.URL] = r.Unchanged
+		}
+		return unchanged
+	}
+
+	if first := crawl(); first["https://example.com/stable"] || first["https://example.com/changes"] {
+		t.Errorf("first crawl flagged %v, want nothing unchanged", first)
+	}
+	pages["https://example.com/changes"] = []byte("after")
+	second := crawl()
+	if !second["https://example.com/stable"] {
+		t.Error("the stable page is not unchanged on the second crawl")
+	}
+	if second["https://example.com/changes"] {
+		t.Error("the changed page is flagged unchanged")
+	}
+}